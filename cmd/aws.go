@@ -3,14 +3,32 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	awsSDK "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/ci-cleaner/pkg/cleaner/aws"
@@ -29,12 +47,23 @@ var (
 	accessKeyID     string
 	secretAccessKey string
 	region          string
+
+	certificateTestZones   []string
+	cleanLogFilters        bool
+	ecrImageTagGracePeriod time.Duration
+	ecrSharedRepositories  []string
+	kmsPendingWindowDays   int64
 )
 
 func init() {
 	AwsCmd.Flags().StringVar(&accessKeyID, "access-key-id", "", "Access key ID.")
 	AwsCmd.Flags().StringVar(&secretAccessKey, "secret-access-key", "", "Secret access key.")
 	AwsCmd.Flags().StringVar(&region, "region", "", "Region.")
+	AwsCmd.Flags().StringSliceVar(&certificateTestZones, "certificate-test-zones", nil, "DNS zones of CI clusters in which certificates of CI domains are deleted.")
+	AwsCmd.Flags().BoolVar(&cleanLogFilters, "clean-log-filters", false, "Delete metric filters and subscription filters of CI clusters from log groups which are kept.")
+	AwsCmd.Flags().DurationVar(&ecrImageTagGracePeriod, "ecr-image-tag-grace-period", 24*time.Hour, "Time CI image tags in shared ECR repositories are kept.")
+	AwsCmd.Flags().StringSliceVar(&ecrSharedRepositories, "ecr-shared-repositories", nil, "Names of shared ECR repositories from which old CI image tags are removed.")
+	AwsCmd.Flags().Int64Var(&kmsPendingWindowDays, "kms-pending-window-days", 7, "Number of days KMS keys stay pending deletion before they are deleted (7-30).")
 }
 
 // runAws runs the AWS related cleaner jobs, prints error output
@@ -49,17 +78,57 @@ func runAws(cmd *cobra.Command, args []string) {
 		fmt.Printf("Problem setting up a new AWS session: %#v\n", err)
 		os.Exit(1)
 	}
+	acmClient := acm.New(s)
+	autoScalingClient := autoscaling.New(s)
 	cfClient := cloudformation.New(s)
+	cloudWatchClient := cloudwatch.New(s)
+	cloudWatchLogsClient := cloudwatchlogs.New(s)
+	dynamoDBClient := dynamodb.New(s)
 	ec2Client := ec2.New(s)
+	ecrClient := ecr.New(s)
+	efsClient := efs.New(s)
+	eksClient := eks.New(s)
+	eventBridgeClient := eventbridge.New(s)
+	iamClient := iam.New(s)
+	kmsClient := kms.New(s)
+	lambdaClient := lambda.New(s)
 	route53Client := route53.New(s)
+	route53ResolverClient := route53resolver.New(s)
 	s3Client := s3.New(s)
+	secretsManagerClient := secretsmanager.New(s)
+	snsClient := sns.New(s)
+	sqsClient := sqs.New(s)
+	ssmClient := ssm.New(s)
 
 	c := &aws.Config{
-		CFClient:      cfClient,
-		EC2Client:     ec2Client,
-		Logger:        logger,
-		Route53Client: route53Client,
-		S3Client:      s3Client,
+		ACMClient:             acmClient,
+		AutoScalingClient:     autoScalingClient,
+		CFClient:              cfClient,
+		CloudWatchClient:      cloudWatchClient,
+		CloudWatchLogsClient:  cloudWatchLogsClient,
+		DynamoDBClient:        dynamoDBClient,
+		EC2Client:             ec2Client,
+		ECRClient:             ecrClient,
+		EFSClient:             efsClient,
+		EKSClient:             eksClient,
+		EventBridgeClient:     eventBridgeClient,
+		IAMClient:             iamClient,
+		KMSClient:             kmsClient,
+		LambdaClient:          lambdaClient,
+		Logger:                logger,
+		Route53Client:         route53Client,
+		Route53ResolverClient: route53ResolverClient,
+		S3Client:              s3Client,
+		SecretsManagerClient:  secretsManagerClient,
+		SNSClient:             snsClient,
+		SQSClient:             sqsClient,
+		SSMClient:             ssmClient,
+
+		CertificateTestZones:   certificateTestZones,
+		CleanLogFilters:        cleanLogFilters,
+		ECRImageTagGracePeriod: ecrImageTagGracePeriod,
+		ECRSharedRepositories:  ecrSharedRepositories,
+		KMSPendingWindowDays:   kmsPendingWindowDays,
 	}
 
 	a, err := aws.New(c)
@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanCertificates deletes the ACM certificates requested for the domains of
+// CI clusters in the configured test zones, e.g. by ingress tests. Certificates
+// are kept as long as they are in use, e.g. by a load balancer which is not
+// deleted yet. Certificates pending validation are deleted as well, since their
+// validation records are gone together with the cluster.
+func (a *Cleaner) cleanCertificates() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	if len(a.certificateTestZones) == 0 {
+		a.logger.Log("level", "debug", "message", "no certificate test zones configured, skipping")
+		return nil
+	}
+
+	var nextToken *string
+	for {
+		i := &acm.ListCertificatesInput{
+			// only RSA 2048 certificates are listed by default.
+			Includes: &acm.Filters{
+				KeyTypes: aws.StringSlice(acm.KeyAlgorithm_Values()),
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.acmClient.ListCertificates(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, certificate := range o.CertificateSummaryList {
+			if !certificateShouldBeDeleted(certificate, a.certificateTestZones) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that certificate %#q for %#q should be deleted", *certificate.CertificateArn, aws.StringValue(certificate.DomainName)))
+
+			i := &acm.DeleteCertificateInput{
+				CertificateArn: certificate.CertificateArn,
+			}
+			_, err := a.acmClient.DeleteCertificate(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("certificate %#q does not exist anymore", *certificate.CertificateArn))
+			} else if IsInUse(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("certificate %#q is still in use", *certificate.CertificateArn))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting certificate %#q: %#v", *certificate.CertificateArn, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted certificate %#q", *certificate.CertificateArn))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func certificateShouldBeDeleted(certificate *acm.CertificateSummary, zones []string) bool {
+	var isCI bool
+	for _, domain := range append([]*string{certificate.DomainName}, certificate.SubjectAlternativeNameSummaries...) {
+		if isCIDomain(aws.StringValue(domain), zones) {
+			isCI = true
+			break
+		}
+	}
+	if !isCI {
+		return false
+	}
+
+	// do not delete certificates which are still used, e.g. by load
+	// balancers.
+	if aws.BoolValue(certificate.InUse) {
+		return false
+	}
+
+	// imported certificates only have an import time.
+	createdAt := certificate.CreatedAt
+	if createdAt == nil {
+		createdAt = certificate.ImportedAt
+	}
+
+	if createdAt == nil {
+		// bad formed certificate, should be deleted
+		return true
+	}
+
+	// do not delete recent certificates.
+	if time.Now().UTC().Sub(*createdAt) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// isCIDomain returns true if the given domain is in one of the given test zones
+// and any label below the zone belongs to a CI cluster, e.g.
+// *.ci-1a2b3.k8s.example.com in the zone k8s.example.com.
+func isCIDomain(domain string, zones []string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	for _, zone := range zones {
+		suffix := "." + strings.ToLower(strings.Trim(zone, "."))
+		if !strings.HasSuffix(domain, suffix) {
+			continue
+		}
+
+		for _, label := range strings.Split(strings.TrimSuffix(domain, suffix), ".") {
+			if isCIResource(label) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+)
+
+func TestCertificateShouldBeDeleted(t *testing.T) {
+	zones := []string{
+		"k8s.example.com",
+	}
+
+	tcs := []struct {
+		certificate *acm.CertificateSummary
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci certificate should be deleted",
+			certificate: &acm.CertificateSummary{
+				CreatedAt:  aws.Time(time.Now().Add(-2 * time.Hour)),
+				DomainName: aws.String("*.ci-wip-1a2b3.k8s.example.com"),
+				InUse:      aws.Bool(false),
+			},
+			expected: true,
+		},
+		{
+			description: "old certificate with ci alternative name should be deleted",
+			certificate: &acm.CertificateSummary{
+				CreatedAt:                       aws.Time(time.Now().Add(-2 * time.Hour)),
+				DomainName:                      aws.String("ingress.example.com"),
+				InUse:                           aws.Bool(false),
+				SubjectAlternativeNameSummaries: aws.StringSlice([]string{"ingress.example.com", "ingress.ci-wip-1a2b3.k8s.example.com"}),
+			},
+			expected: true,
+		},
+		{
+			description: "old imported ci certificate should be deleted",
+			certificate: &acm.CertificateSummary{
+				DomainName: aws.String("*.ci-wip-1a2b3.k8s.example.com"),
+				ImportedAt: aws.Time(time.Now().Add(-2 * time.Hour)),
+				InUse:      aws.Bool(false),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci certificate should not be deleted",
+			certificate: &acm.CertificateSummary{
+				CreatedAt:  aws.Time(time.Now()),
+				DomainName: aws.String("*.ci-wip-1a2b3.k8s.example.com"),
+				InUse:      aws.Bool(false),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci certificate in use should not be deleted",
+			certificate: &acm.CertificateSummary{
+				CreatedAt:  aws.Time(time.Now().Add(-2 * time.Hour)),
+				DomainName: aws.String("*.ci-wip-1a2b3.k8s.example.com"),
+				InUse:      aws.Bool(true),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci certificate outside of test zones should not be deleted",
+			certificate: &acm.CertificateSummary{
+				CreatedAt:  aws.Time(time.Now().Add(-2 * time.Hour)),
+				DomainName: aws.String("ci-tools.example.com"),
+				InUse:      aws.Bool(false),
+			},
+			expected: false,
+		},
+		{
+			description: "old other certificate should not be deleted",
+			certificate: &acm.CertificateSummary{
+				CreatedAt:  aws.Time(time.Now().Add(-2 * time.Hour)),
+				DomainName: aws.String("*.k8s.example.com"),
+				InUse:      aws.Bool(false),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := certificateShouldBeDeleted(tc.certificate, zones)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.certificate.DomainName, tc.expected, actual)
+			}
+		})
+	}
+}
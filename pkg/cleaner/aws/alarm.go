@@ -0,0 +1,261 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+var (
+	// alarmRuleArgument matches the arguments of the state functions used in
+	// composite alarm rules.
+	alarmRuleArgument = regexp.MustCompile(`(?:ALARM|OK|INSUFFICIENT_DATA)\s*\(\s*("[^"]*"|[^)]*)\)`)
+)
+
+const (
+	// maxDeleteAlarms is the maximum number of CloudWatch alarms which can be
+	// deleted in a single request.
+	maxDeleteAlarms = 100
+)
+
+// cleanAlarms deletes the CloudWatch alarms of CI clusters, e.g. created by
+// monitoring tests. Alarms used in the rule of a composite alarm cannot be
+// deleted before the composite alarm, so composite alarms are deleted first,
+// starting with the ones no other composite alarm depends on. Alarms do not
+// have a creation time, so the time of their last configuration update is
+// used instead.
+func (a *Cleaner) cleanAlarms() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var metricAlarms []*cloudwatch.MetricAlarm
+	var compositeAlarms []*cloudwatch.CompositeAlarm
+	{
+		var nextToken *string
+		for {
+			i := &cloudwatch.DescribeAlarmsInput{
+				AlarmTypes: aws.StringSlice(cloudwatch.AlarmType_Values()),
+				NextToken:  nextToken,
+			}
+
+			o, err := a.cloudWatchClient.DescribeAlarms(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			metricAlarms = append(metricAlarms, o.MetricAlarms...)
+			compositeAlarms = append(compositeAlarms, o.CompositeAlarms...)
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	var composites []*cloudwatch.CompositeAlarm
+	for _, alarm := range compositeAlarms {
+		ok, err := a.shouldDeleteAlarm(alarm.AlarmName, alarm.AlarmArn, alarm.AlarmConfigurationUpdatedTimestamp)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of alarm %#q: %#v", *alarm.AlarmName, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+		if ok {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that composite alarm %#q should be deleted", *alarm.AlarmName))
+			composites = append(composites, alarm)
+		}
+	}
+
+	var names []*string
+	for _, alarm := range metricAlarms {
+		ok, err := a.shouldDeleteAlarm(alarm.AlarmName, alarm.AlarmArn, alarm.AlarmConfigurationUpdatedTimestamp)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of alarm %#q: %#v", *alarm.AlarmName, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+		if ok {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that alarm %#q should be deleted", *alarm.AlarmName))
+			names = append(names, alarm.AlarmName)
+		}
+	}
+
+	// composite alarms are deleted in rounds, each time deleting the ones no
+	// remaining composite alarm depends on.
+	remaining := compositeAlarms
+	for len(composites) > 0 {
+		var deletable []*string
+		var blocked []*cloudwatch.CompositeAlarm
+		for _, alarm := range composites {
+			if isAlarmReferenced(*alarm.AlarmName, remaining) {
+				blocked = append(blocked, alarm)
+			} else {
+				deletable = append(deletable, alarm.AlarmName)
+			}
+		}
+
+		if len(deletable) == 0 {
+			for _, alarm := range blocked {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("composite alarm %#q cannot be deleted, since other composite alarms depend on it", *alarm.AlarmName))
+			}
+			break
+		}
+
+		err := a.deleteAlarms(deletable)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			break
+		}
+
+		deleted := map[string]bool{}
+		for _, name := range deletable {
+			deleted[*name] = true
+		}
+		var left []*cloudwatch.CompositeAlarm
+		for _, alarm := range remaining {
+			if !deleted[*alarm.AlarmName] {
+				left = append(left, alarm)
+			}
+		}
+
+		remaining = left
+		composites = blocked
+	}
+
+	// metric alarms used in the rule of a remaining composite alarm, e.g. of a
+	// composite alarm which is not deleted, cannot be deleted.
+	var deletable []*string
+	for _, name := range names {
+		if isAlarmReferenced(*name, remaining) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("alarm %#q cannot be deleted, since composite alarms depend on it", *name))
+			continue
+		}
+		deletable = append(deletable, name)
+	}
+
+	err := a.deleteAlarms(deletable)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteAlarms(names []*string) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	for len(names) > 0 {
+		n := len(names)
+		if n > maxDeleteAlarms {
+			n = maxDeleteAlarms
+		}
+
+		i := &cloudwatch.DeleteAlarmsInput{
+			AlarmNames: names[:n],
+		}
+		_, err := a.cloudWatchClient.DeleteAlarms(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting alarms %s: %#v", strings.Join(aws.StringValueSlice(names[:n]), ", "), err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			for _, name := range names[:n] {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted alarm %#q", *name))
+			}
+		}
+
+		names = names[n:]
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// shouldDeleteAlarm fetches the tags of the alarm with the given name and
+// ARN in case it is not recognized by name and checks if it should be deleted.
+func (a *Cleaner) shouldDeleteAlarm(name, arn *string, updatedAt *time.Time) (bool, error) {
+	// alarms are listed without their tags, so we only fetch them for alarms
+	// which could be deleted and are not recognized by name.
+	var tags map[string]string
+	if !isCIResource(aws.StringValue(name)) && !isAlarmRecent(updatedAt) {
+		i := &cloudwatch.ListTagsForResourceInput{
+			ResourceARN: arn,
+		}
+		o, err := a.cloudWatchClient.ListTagsForResource(i)
+		if IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		tags = map[string]string{}
+		for _, t := range o.Tags {
+			if t.Key == nil || t.Value == nil {
+				continue
+			}
+			tags[*t.Key] = *t.Value
+		}
+	}
+
+	return alarmShouldBeDeleted(aws.StringValue(name), updatedAt, tags), nil
+}
+
+func alarmShouldBeDeleted(name string, updatedAt *time.Time, tags map[string]string) bool {
+	if !isCIResource(name) && !isCITagged(tags) {
+		return false
+	}
+
+	// do not delete recently updated alarms.
+	return !isAlarmRecent(updatedAt)
+}
+
+func isAlarmRecent(updatedAt *time.Time) bool {
+	if updatedAt == nil {
+		return false
+	}
+
+	return time.Now().UTC().Sub(*updatedAt) < gracePeriod
+}
+
+// isAlarmReferenced returns true if the alarm with the given name is used in
+// the rule of any of the given other composite alarms.
+func isAlarmReferenced(name string, others []*cloudwatch.CompositeAlarm) bool {
+	for _, other := range others {
+		if aws.StringValue(other.AlarmName) == name {
+			continue
+		}
+
+		if isAlarmInRule(name, aws.StringValue(other.AlarmRule)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAlarmInRule returns true if the alarm with the given name is an argument
+// of any state function in the given composite alarm rule, e.g.
+// ALARM("name"), OK(name) or ALARM(arn:aws:cloudwatch:...:alarm:name). Alarms
+// are referenced by name or ARN, which ends with the name.
+func isAlarmInRule(name, rule string) bool {
+	for _, m := range alarmRuleArgument.FindAllStringSubmatch(rule, -1) {
+		arg := strings.Trim(strings.TrimSpace(m[1]), `"`)
+		if arg == name || strings.HasSuffix(arg, ":alarm:"+name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+func TestAlarmShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		name        string
+		updatedAt   *time.Time
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci alarm should be deleted",
+			name:        "ci-wip-1a2b3-apiserver-errors",
+			updatedAt:   aws.Time(time.Now().Add(-2 * time.Hour)),
+			expected:    true,
+		},
+		{
+			description: "old alarm tagged for ci cluster should be deleted",
+			name:        "apiserver-errors",
+			updatedAt:   aws.Time(time.Now().Add(-2 * time.Hour)),
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recently updated ci alarm should not be deleted",
+			name:        "ci-wip-1a2b3-apiserver-errors",
+			updatedAt:   aws.Time(time.Now()),
+			expected:    false,
+		},
+		{
+			description: "old non ci alarm should not be deleted",
+			name:        "billing-alert",
+			updatedAt:   aws.Time(time.Now().Add(-2 * time.Hour)),
+			tags:        map[string]string{},
+			expected:    false,
+		},
+		{
+			description: "ci alarm without update time should be deleted",
+			name:        "ci-wip-1a2b3-apiserver-errors",
+			expected:    true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := alarmShouldBeDeleted(tc.name, tc.updatedAt, tc.tags)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.name, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestIsAlarmReferenced(t *testing.T) {
+	tcs := []struct {
+		alarm       *cloudwatch.CompositeAlarm
+		others      []*cloudwatch.CompositeAlarm
+		expected    bool
+		description string
+	}{
+		{
+			description: "alarm referenced by name should be referenced",
+			alarm: &cloudwatch.CompositeAlarm{
+				AlarmName: aws.String("ci-wip-1a2b3-control-plane"),
+			},
+			others: []*cloudwatch.CompositeAlarm{
+				{
+					AlarmName: aws.String("ci-wip-1a2b3-cluster"),
+					AlarmRule: aws.String(`ALARM("ci-wip-1a2b3-control-plane") OR ALARM("ci-wip-1a2b3-workers")`),
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "alarm referenced by arn should be referenced",
+			alarm: &cloudwatch.CompositeAlarm{
+				AlarmName: aws.String("ci-wip-1a2b3-control-plane"),
+			},
+			others: []*cloudwatch.CompositeAlarm{
+				{
+					AlarmName: aws.String("ci-wip-1a2b3-cluster"),
+					AlarmRule: aws.String("ALARM(arn:aws:cloudwatch:eu-central-1:123456789012:alarm:ci-wip-1a2b3-control-plane)"),
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "alarm whose name is a prefix of a referenced alarm should not be referenced",
+			alarm: &cloudwatch.CompositeAlarm{
+				AlarmName: aws.String("ci-a"),
+			},
+			others: []*cloudwatch.CompositeAlarm{
+				{
+					AlarmName: aws.String("ci-cluster"),
+					AlarmRule: aws.String(`ALARM("ci-ab") OR ALARM(arn:aws:cloudwatch:eu-central-1:123456789012:alarm:ci-abc)`),
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "alarm referenced with ok state should be referenced",
+			alarm: &cloudwatch.CompositeAlarm{
+				AlarmName: aws.String("ci-a"),
+			},
+			others: []*cloudwatch.CompositeAlarm{
+				{
+					AlarmName: aws.String("production"),
+					AlarmRule: aws.String(`ALARM("ci-ab") AND NOT OK( "ci-a" )`),
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "alarm not used in any rule should not be referenced",
+			alarm: &cloudwatch.CompositeAlarm{
+				AlarmName: aws.String("ci-wip-1a2b3-cluster"),
+				AlarmRule: aws.String(`ALARM("ci-wip-1a2b3-control-plane")`),
+			},
+			others: []*cloudwatch.CompositeAlarm{
+				{
+					AlarmName: aws.String("ci-wip-1a2b3-cluster"),
+					AlarmRule: aws.String(`ALARM("ci-wip-1a2b3-control-plane")`),
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := isAlarmReferenced(*tc.alarm.AlarmName, tc.others)
+			if result != tc.expected {
+				t.Errorf("checking if %q is referenced, want %t, got %t", *tc.alarm.AlarmName, tc.expected, result)
+			}
+		})
+	}
+}
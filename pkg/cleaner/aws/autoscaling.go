@@ -0,0 +1,368 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// autoScalingGroupStatusDeleting is the status of Auto Scaling groups
+	// which are being deleted.
+	autoScalingGroupStatusDeleting = "Delete in progress"
+	// maxInstanceProtectionInstances is the maximum number of instances the
+	// scale-in protection can be changed for in a single request.
+	maxInstanceProtectionInstances = 50
+)
+
+// autoScalingReferences holds the launch configurations, launch templates and
+// key pairs which are still referenced by existing Auto Scaling groups and
+// must therefore not be deleted.
+type autoScalingReferences struct {
+	// keyPairs holds the names of the referenced key pairs.
+	keyPairs map[string]bool
+	// launchConfigurations holds the names of the referenced launch
+	// configurations.
+	launchConfigurations map[string]bool
+	// launchTemplates holds the IDs and names of the referenced launch
+	// templates.
+	launchTemplates map[string]bool
+}
+
+// cleanAutoScalingGroups deletes Auto Scaling groups of CI clusters which were
+// not created by CloudFormation, e.g. by cluster-api or Karpenter. These
+// groups keep relaunching the instances we terminate, so they are force
+// deleted together with their instances. Groups of existing stacks are left to
+// the stack deletion.
+func (a *Cleaner) cleanAutoScalingGroups() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stacks, err := a.stacks()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	groups, err := a.describeAutoScalingGroups()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var deleting []*string
+	for _, group := range groups {
+		if !autoScalingGroupShouldBeDeleted(group, stacks) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that auto scaling group %#q should be deleted", *group.AutoScalingGroupName))
+
+		err := a.deleteAutoScalingGroup(group)
+		if IsNotFound(err) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("auto scaling group %#q does not exist anymore", *group.AutoScalingGroupName))
+			continue
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting auto scaling group %#q: %#v", *group.AutoScalingGroupName, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		deleting = append(deleting, group.AutoScalingGroupName)
+	}
+
+	if len(deleting) > 0 {
+		// the instances of the groups block the deletion of their VPCs, launch
+		// templates and key pairs until the groups are gone.
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for %d auto scaling groups to be deleted", len(deleting)))
+
+		i := &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: deleting,
+		}
+		err := a.autoScalingClient.WaitUntilGroupNotExists(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed waiting for auto scaling groups to be deleted: %#v", err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted %d auto scaling groups", len(deleting)))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteAutoScalingGroup force deletes the given group. Its processes are
+// suspended first, so that it does not launch or replace instances anymore.
+// Scale-in protection and lifecycle hooks are removed, since they would
+// otherwise delay the termination of the instances.
+func (a *Cleaner) deleteAutoScalingGroup(group *autoscaling.Group) error {
+	{
+		i := &autoscaling.ScalingProcessQuery{
+			AutoScalingGroupName: group.AutoScalingGroupName,
+		}
+		_, err := a.autoScalingClient.SuspendProcesses(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if aws.BoolValue(group.NewInstancesProtectedFromScaleIn) {
+		i := &autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName:             group.AutoScalingGroupName,
+			NewInstancesProtectedFromScaleIn: aws.Bool(false),
+		}
+		_, err := a.autoScalingClient.UpdateAutoScalingGroup(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		var protected []*string
+		for _, instance := range group.Instances {
+			if aws.BoolValue(instance.ProtectedFromScaleIn) {
+				protected = append(protected, instance.InstanceId)
+			}
+		}
+
+		for len(protected) > 0 {
+			n := len(protected)
+			if n > maxInstanceProtectionInstances {
+				n = maxInstanceProtectionInstances
+			}
+
+			i := &autoscaling.SetInstanceProtectionInput{
+				AutoScalingGroupName: group.AutoScalingGroupName,
+				InstanceIds:          protected[:n],
+				ProtectedFromScaleIn: aws.Bool(false),
+			}
+			_, err := a.autoScalingClient.SetInstanceProtection(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			protected = protected[n:]
+		}
+	}
+
+	{
+		i := &autoscaling.DescribeLifecycleHooksInput{
+			AutoScalingGroupName: group.AutoScalingGroupName,
+		}
+		o, err := a.autoScalingClient.DescribeLifecycleHooks(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, hook := range o.LifecycleHooks {
+			i := &autoscaling.DeleteLifecycleHookInput{
+				AutoScalingGroupName: group.AutoScalingGroupName,
+				LifecycleHookName:    hook.LifecycleHookName,
+			}
+			_, err := a.autoScalingClient.DeleteLifecycleHook(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		i := &autoscaling.DeleteAutoScalingGroupInput{
+			AutoScalingGroupName: group.AutoScalingGroupName,
+			ForceDelete:          aws.Bool(true),
+		}
+		_, err := a.autoScalingClient.DeleteAutoScalingGroup(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) describeAutoScalingGroups() ([]*autoscaling.Group, error) {
+	var groups []*autoscaling.Group
+
+	var nextToken *string
+	for {
+		i := &autoscaling.DescribeAutoScalingGroupsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.autoScalingClient.DescribeAutoScalingGroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		groups = append(groups, o.AutoScalingGroups...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return groups, nil
+}
+
+func (a *Cleaner) describeLaunchConfigurations() ([]*autoscaling.LaunchConfiguration, error) {
+	var configs []*autoscaling.LaunchConfiguration
+
+	var nextToken *string
+	for {
+		i := &autoscaling.DescribeLaunchConfigurationsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.autoScalingClient.DescribeLaunchConfigurations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		configs = append(configs, o.LaunchConfigurations...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return configs, nil
+}
+
+// autoScalingReferences returns the resources referenced by all existing Auto
+// Scaling groups. Key pairs are referenced indirectly through the launch
+// configurations and launch template versions the groups use.
+func (a *Cleaner) autoScalingReferences() (*autoScalingReferences, error) {
+	groups, err := a.describeAutoScalingGroups()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	refs := &autoScalingReferences{
+		keyPairs:             map[string]bool{},
+		launchConfigurations: map[string]bool{},
+		launchTemplates:      map[string]bool{},
+	}
+
+	var specs []*autoscaling.LaunchTemplateSpecification
+	for _, group := range groups {
+		if group.LaunchConfigurationName != nil {
+			refs.launchConfigurations[*group.LaunchConfigurationName] = true
+		}
+		if group.LaunchTemplate != nil {
+			specs = append(specs, group.LaunchTemplate)
+		}
+		if group.MixedInstancesPolicy != nil && group.MixedInstancesPolicy.LaunchTemplate != nil && group.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification != nil {
+			specs = append(specs, group.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification)
+		}
+	}
+
+	if len(refs.launchConfigurations) > 0 {
+		configs, err := a.describeLaunchConfigurations()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, lc := range configs {
+			if refs.launchConfigurations[aws.StringValue(lc.LaunchConfigurationName)] && aws.StringValue(lc.KeyName) != "" {
+				refs.keyPairs[*lc.KeyName] = true
+			}
+		}
+	}
+
+	for _, spec := range specs {
+		if spec.LaunchTemplateId != nil {
+			refs.launchTemplates[*spec.LaunchTemplateId] = true
+		}
+		if spec.LaunchTemplateName != nil {
+			refs.launchTemplates[*spec.LaunchTemplateName] = true
+		}
+
+		// groups without an explicit version use the default version of the
+		// launch template.
+		version := aws.StringValue(spec.Version)
+		if version == "" {
+			version = "$Default"
+		}
+
+		i := &ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId:   spec.LaunchTemplateId,
+			LaunchTemplateName: spec.LaunchTemplateName,
+			Versions: []*string{
+				aws.String(version),
+			},
+		}
+		o, err := a.ec2Client.DescribeLaunchTemplateVersions(i)
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, v := range o.LaunchTemplateVersions {
+			if v.LaunchTemplateData != nil && aws.StringValue(v.LaunchTemplateData.KeyName) != "" {
+				refs.keyPairs[*v.LaunchTemplateData.KeyName] = true
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+func autoScalingGroupShouldBeDeleted(group *autoscaling.Group, stacks map[string]*cloudformation.Stack) bool {
+	// do not delete groups that are already being deleted.
+	if aws.StringValue(group.Status) == autoScalingGroupStatusDeleting {
+		return false
+	}
+
+	tags := autoScalingTags(group.Tags)
+
+	if !isCITagged(tags) {
+		return false
+	}
+
+	// groups of existing stacks are deleted together with their stack. We
+	// only take over in case the deletion of the stack failed.
+	if name, ok := tags[tagCloudFormationStack]; ok {
+		stack, ok := stacks[name]
+		if ok && aws.StringValue(stack.StackStatus) != cloudformation.StackStatusDeleteFailed {
+			return false
+		}
+	}
+
+	if group.CreatedTime == nil {
+		// bad formed group, should be deleted
+		return true
+	}
+
+	// do not delete recent groups.
+	if time.Now().UTC().Sub(*group.CreatedTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// autoScalingTags converts the given Auto Scaling group tags into a map of tag
+// keys and values.
+func autoScalingTags(tags []*autoscaling.TagDescription) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		m[*t.Key] = *t.Value
+	}
+
+	return m
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestAutoScalingGroupShouldBeDeleted(t *testing.T) {
+	stacks := map[string]*cloudformation.Stack{
+		"cluster-ci-existing-tcnp-1": {
+			StackName:   aws.String("cluster-ci-existing-tcnp-1"),
+			StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+		},
+		"cluster-ci-failed-tcnp-1": {
+			StackName:   aws.String("cluster-ci-failed-tcnp-1"),
+			StackStatus: aws.String(cloudformation.StackStatusDeleteFailed),
+		},
+	}
+
+	tcs := []struct {
+		group       *autoscaling.Group
+		expected    bool
+		description string
+	}{
+		{
+			description: "old group tagged for ci cluster should be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("ci-wip-1a2b3-md-0"),
+				CreatedTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String("sigs.k8s.io/cluster-api-provider-aws/cluster/ci-wip-1a2b3"),
+						Value: aws.String("owned"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent group tagged for ci cluster should not be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("ci-wip-1a2b3-md-0"),
+				CreatedTime:          aws.Time(time.Now()),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old group being deleted should not be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("ci-wip-1a2b3-md-0"),
+				CreatedTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				Status:               aws.String("Delete in progress"),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old group of existing stack should not be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("cluster-ci-existing-tcnp-1-NodePoolAutoScalingGroup-ABC"),
+				CreatedTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String(tagCloudFormationStack),
+						Value: aws.String("cluster-ci-existing-tcnp-1"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old group of stack failed to be deleted should be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("cluster-ci-failed-tcnp-1-NodePoolAutoScalingGroup-ABC"),
+				CreatedTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String(tagCloudFormationStack),
+						Value: aws.String("cluster-ci-failed-tcnp-1"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old group of missing stack should be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("cluster-ci-missing-tcnp-1-NodePoolAutoScalingGroup-ABC"),
+				CreatedTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String(tagCloudFormationStack),
+						Value: aws.String("cluster-ci-missing-tcnp-1"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old other group should not be deleted",
+			group: &autoscaling.Group{
+				AutoScalingGroupName: aws.String("abc12-md-0"),
+				CreatedTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*autoscaling.TagDescription{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("abc12"),
+					},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := autoScalingGroupShouldBeDeleted(tc.group, stacks)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.group.AutoScalingGroupName, tc.expected, actual)
+			}
+		})
+	}
+}
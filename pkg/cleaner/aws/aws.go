@@ -19,44 +19,182 @@ import (
 )
 
 type Config struct {
-	EC2Client     EC2Client
-	CFClient      CFClient
-	Logger        micrologger.Logger
-	Route53Client Route53Client
-	S3Client      S3Client
+	ACMClient             ACMClient
+	AutoScalingClient     AutoScalingClient
+	CloudWatchClient      CloudWatchClient
+	CloudWatchLogsClient  CloudWatchLogsClient
+	DynamoDBClient        DynamoDBClient
+	EC2Client             EC2Client
+	ECRClient             ECRClient
+	EFSClient             EFSClient
+	EKSClient             EKSClient
+	EventBridgeClient     EventBridgeClient
+	CFClient              CFClient
+	IAMClient             IAMClient
+	KMSClient             KMSClient
+	LambdaClient          LambdaClient
+	Logger                micrologger.Logger
+	Route53Client         Route53Client
+	Route53ResolverClient Route53ResolverClient
+	S3Client              S3Client
+	SecretsManagerClient  SecretsManagerClient
+	SNSClient             SNSClient
+	SQSClient             SQSClient
+	SSMClient             SSMClient
+
+	// CertificateTestZones are the DNS zones CI clusters create certificates
+	// in. Only certificates of CI domains within these zones are deleted.
+	CertificateTestZones []string
+	// CleanLogFilters enables the deletion of metric filters and
+	// subscription filters of CI clusters from log groups which are kept.
+	CleanLogFilters bool
+	// ECRImageTagGracePeriod is the time CI image tags in shared ECR
+	// repositories are kept.
+	ECRImageTagGracePeriod time.Duration
+	// ECRSharedRepositories are the names of the ECR repositories shared by CI
+	// runs and releases, from which old CI image tags are removed. Other
+	// repositories which are not named for a CI cluster are not touched.
+	ECRSharedRepositories []string
+	// KMSPendingWindowDays is the number of days KMS keys of CI clusters stay
+	// pending deletion before they are deleted.
+	KMSPendingWindowDays int64
 }
 
 type Cleaner struct {
-	ec2Client     EC2Client
-	cfClient      CFClient
-	logger        micrologger.Logger
-	route53Client Route53Client
-	s3Client      S3Client
+	acmClient             ACMClient
+	autoScalingClient     AutoScalingClient
+	cloudWatchClient      CloudWatchClient
+	cloudWatchLogsClient  CloudWatchLogsClient
+	dynamoDBClient        DynamoDBClient
+	ec2Client             EC2Client
+	ecrClient             ECRClient
+	efsClient             EFSClient
+	eksClient             EKSClient
+	eventBridgeClient     EventBridgeClient
+	cfClient              CFClient
+	iamClient             IAMClient
+	kmsClient             KMSClient
+	lambdaClient          LambdaClient
+	logger                micrologger.Logger
+	route53Client         Route53Client
+	route53ResolverClient Route53ResolverClient
+	s3Client              S3Client
+	secretsManagerClient  SecretsManagerClient
+	snsClient             SNSClient
+	sqsClient             SQSClient
+	ssmClient             SSMClient
+
+	certificateTestZones   []string
+	cleanLogFilters        bool
+	ecrImageTagGracePeriod time.Duration
+	ecrSharedRepositories  []string
+	kmsPendingWindowDays   int64
 }
 
 func New(config *Config) (*Cleaner, error) {
+	if config.ACMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ACMClient must not be empty", config)
+	}
+	if config.AutoScalingClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AutoScalingClient must not be empty", config)
+	}
+	if config.CloudWatchClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CloudWatchClient must not be empty", config)
+	}
+	if config.CloudWatchLogsClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CloudWatchLogsClient must not be empty", config)
+	}
 	if config.CFClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.CFClient must not be empty", config)
 	}
+	if config.DynamoDBClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DynamoDBClient must not be empty", config)
+	}
 	if config.EC2Client == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.ec2lient must not be empty", config)
 	}
+	if config.ECRClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ECRClient must not be empty", config)
+	}
+	if config.EFSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EFSClient must not be empty", config)
+	}
+	if config.EKSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EKSClient must not be empty", config)
+	}
+	if config.EventBridgeClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.EventBridgeClient must not be empty", config)
+	}
+	if config.IAMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.IAMClient must not be empty", config)
+	}
+	if config.KMSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KMSClient must not be empty", config)
+	}
+	if config.LambdaClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LambdaClient must not be empty", config)
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
 	if config.Route53Client == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Route53Client must not be empty", config)
 	}
+	if config.Route53ResolverClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Route53ResolverClient must not be empty", config)
+	}
 	if config.S3Client == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.S3Client must not be empty", config)
 	}
+	if config.SecretsManagerClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SecretsManagerClient must not be empty", config)
+	}
+	if config.SNSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SNSClient must not be empty", config)
+	}
+	if config.SQSClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SQSClient must not be empty", config)
+	}
+	if config.SSMClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.SSMClient must not be empty", config)
+	}
+
+	if len(config.ECRSharedRepositories) > 0 && config.ECRImageTagGracePeriod < gracePeriod {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ECRImageTagGracePeriod must be at least %s", config, gracePeriod)
+	}
+	if config.KMSPendingWindowDays < minKMSPendingWindowDays || config.KMSPendingWindowDays > maxKMSPendingWindowDays {
+		return nil, microerror.Maskf(invalidConfigError, "%T.KMSPendingWindowDays must be between %d and %d", config, minKMSPendingWindowDays, maxKMSPendingWindowDays)
+	}
 
 	cleaner := &Cleaner{
-		ec2Client:     config.EC2Client,
-		cfClient:      config.CFClient,
-		logger:        config.Logger,
-		route53Client: config.Route53Client,
-		s3Client:      config.S3Client,
+		acmClient:             config.ACMClient,
+		autoScalingClient:     config.AutoScalingClient,
+		cloudWatchClient:      config.CloudWatchClient,
+		cloudWatchLogsClient:  config.CloudWatchLogsClient,
+		dynamoDBClient:        config.DynamoDBClient,
+		ec2Client:             config.EC2Client,
+		ecrClient:             config.ECRClient,
+		efsClient:             config.EFSClient,
+		eksClient:             config.EKSClient,
+		eventBridgeClient:     config.EventBridgeClient,
+		cfClient:              config.CFClient,
+		iamClient:             config.IAMClient,
+		kmsClient:             config.KMSClient,
+		lambdaClient:          config.LambdaClient,
+		logger:                config.Logger,
+		route53Client:         config.Route53Client,
+		route53ResolverClient: config.Route53ResolverClient,
+		s3Client:              config.S3Client,
+		secretsManagerClient:  config.SecretsManagerClient,
+		snsClient:             config.SNSClient,
+		sqsClient:             config.SQSClient,
+		ssmClient:             config.SSMClient,
+
+		certificateTestZones:   config.CertificateTestZones,
+		cleanLogFilters:        config.CleanLogFilters,
+		ecrImageTagGracePeriod: config.ECRImageTagGracePeriod,
+		ecrSharedRepositories:  config.ECRSharedRepositories,
+		kmsPendingWindowDays:   config.KMSPendingWindowDays,
 	}
 
 	return cleaner, nil
@@ -67,6 +205,28 @@ func getFunctionName(i interface{}) string {
 	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
 }
 
+// waitFor calls the given condition function until it returns true, returns
+// an error or waitTimeout is exceeded.
+func waitFor(condition func() (bool, error)) error {
+	deadline := time.Now().Add(waitTimeout)
+
+	for {
+		done, err := condition()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if done {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return microerror.Maskf(timeoutError, "waited %s", waitTimeout)
+		}
+
+		time.Sleep(waitInterval)
+	}
+}
+
 // Clean calls our cleaner functions and logs errors if they happen.
 // We don't return errors as we want all cleaners to be called.
 func (a *Cleaner) Clean() error {
@@ -74,7 +234,44 @@ func (a *Cleaner) Clean() error {
 
 	cleaners := []cleanerFn{
 		a.cleanStacks,
+		a.cleanAutoScalingGroups,
+		a.cleanSpotInstanceRequests,
+		a.cleanSpotFleetRequests,
+		a.cleanEKSClusters,
+		a.cleanLambdaFunctions,
+		a.cleanEFSFileSystems,
+		a.cleanResolverRules,
+		a.cleanResolverEndpoints,
+		a.cleanNatGateways,
+		a.cleanVPCPeeringConnections,
+		a.cleanTransitGatewayAttachments,
+		a.cleanVPCEndpoints,
+		a.cleanVPCEndpointServices,
+		a.cleanNetworkInterfaces,
+		a.cleanElasticIPs,
+		a.cleanVPCs,
+		a.cleanFlowLogs,
+		a.cleanDHCPOptionSets,
+		a.cleanPrefixLists,
+		a.cleanLaunchConfigurations,
+		a.cleanLaunchTemplates,
+		a.cleanKeyPairs,
+		a.cleanIAMInstanceProfiles,
+		a.cleanIAMRoles,
+		a.cleanIAMPolicies,
+		a.cleanKMSKeys,
 		a.cleanBuckets,
+		a.cleanDynamoDBTables,
+		a.cleanIAMOIDCProviders,
+		a.cleanLogGroups,
+		a.cleanECRRepositories,
+		a.cleanEventBridgeRules,
+		a.cleanSQSQueues,
+		a.cleanSecrets,
+		a.cleanSSMParameters,
+		a.cleanCertificates,
+		a.cleanAlarms,
+		a.cleanSNSTopics,
 		// NOTE this can be enable when needed for further cleanups.
 		// a.cleanHostedZones,
 	}
@@ -159,6 +356,34 @@ func (a *Cleaner) cleanStacks() error {
 	return nil
 }
 
+// stacks returns all CloudFormation stacks mapped by their name.
+func (a *Cleaner) stacks() (map[string]*cloudformation.Stack, error) {
+	stacks := map[string]*cloudformation.Stack{}
+
+	var nextToken *string
+	for {
+		i := &cloudformation.DescribeStacksInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.cfClient.DescribeStacks(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, stack := range o.Stacks {
+			stacks[*stack.StackName] = stack
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return stacks, nil
+}
+
 func (a *Cleaner) cleanBuckets() error {
 	errors := &errorcollection.ErrorCollection{}
 
@@ -242,6 +467,12 @@ func stackShouldBeDeleted(stack *cloudformation.Stack) bool {
 		return false
 	}
 
+	return isCIResource(*stack.StackName)
+}
+
+// isCIResource returns true if the given name belongs to a resource created by
+// CI, e.g. a stack, a cluster ID or a name derived from a cluster ID.
+func isCIResource(s string) bool {
 	prefixes := []string{
 		"cluster-ci-",
 		"host-peer-ci-",
@@ -249,7 +480,7 @@ func stackShouldBeDeleted(stack *cloudformation.Stack) bool {
 		"ci-",
 	}
 	for _, prefix := range prefixes {
-		if strings.HasPrefix(*stack.StackName, prefix) {
+		if strings.HasPrefix(s, prefix) {
 			return true
 		}
 	}
@@ -257,6 +488,150 @@ func stackShouldBeDeleted(stack *cloudformation.Stack) bool {
 	return false
 }
 
+// ciClusterID returns the ID of the CI cluster the given name starts with,
+// e.g. ci-wip-1a2b3 for ci-wip-1a2b3-dns-handler or
+// cluster-ci-wip-1a2b3-guest-main. Cluster IDs end with one or two parts of
+// five characters containing a digit, so that names of long-lived CI tooling
+// like ci-cleaner or e2e-harness do not match. An empty string is returned in
+// case the name does not start with a CI cluster ID.
+func ciClusterID(s string) string {
+	for _, prefix := range []string{"cluster-", "host-peer-"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	if !strings.HasPrefix(s, "ci-") && !strings.HasPrefix(s, "e2e-") {
+		return ""
+	}
+
+	parts := strings.Split(s, "-")
+	for i := 1; i < len(parts); i++ {
+		if isClusterIDPart(parts[i]) {
+			n := i + 1
+			if n < len(parts) && isClusterIDPart(parts[n]) {
+				n++
+			}
+
+			return strings.Join(parts[:n], "-")
+		}
+
+		// the parts before the ID are words, e.g. wip or cur.
+		if parts[i] == "" || strings.Trim(parts[i], "abcdefghijklmnopqrstuvwxyz") != "" {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func isClusterIDPart(s string) bool {
+	if len(s) != 5 || strings.Trim(s, "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+		return false
+	}
+
+	return strings.ContainsAny(s, "0123456789")
+}
+
+// ciClusterFromPath returns the ID of the CI cluster the given slash separated
+// path belongs to, e.g. ci-wip-1a2b3 for /giantswarm/ci-wip-1a2b3/bootstrap.
+// Only the leading segment is considered, optionally below the giantswarm
+// namespace, so that paths like /production/ci-settings/foo do not match.
+func ciClusterFromPath(s string) string {
+	segments := strings.Split(strings.TrimPrefix(s, "/"), "/")
+	if len(segments) > 1 && segments[0] == "giantswarm" {
+		segments = segments[1:]
+	}
+
+	return ciClusterID(segments[0])
+}
+
+// isTerraformCIResource returns true if the given name belongs to a resource
+// created by the Terraform e2e tests.
+func isTerraformCIResource(s string) bool {
+	return strings.HasPrefix(s, terraformCIPrefix)
+}
+
+// isCITagged returns true if the given tags mark a resource as belonging to a
+// CI cluster or CI stack.
+func isCITagged(tags map[string]string) bool {
+	return ciClusterFromTags(tags) != ""
+}
+
+// ciClusterFromTags returns the name of the CI cluster or CI stack the given
+// tags refer to. An empty string is returned in case the tags do not refer to
+// any CI cluster.
+func ciClusterFromTags(tags map[string]string) string {
+	keys := []string{
+		tagCluster,
+		tagCNICluster,
+		tagCloudFormationStack,
+		tagName,
+	}
+	for _, k := range keys {
+		if isCIResource(tags[k]) {
+			return tags[k]
+		}
+	}
+
+	prefixes := []string{
+		tagKubernetesClusterPrefix,
+		tagClusterAPIClusterPrefix,
+	}
+	for k := range tags {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(k, prefix) && isCIResource(strings.TrimPrefix(k, prefix)) {
+				return strings.TrimPrefix(k, prefix)
+			}
+		}
+	}
+
+	return ""
+}
+
+// isFirstSeenBeforeGracePeriod returns true if the given tags tell that the
+// cleaner saw the resource first longer than gracePeriod ago.
+func isFirstSeenBeforeGracePeriod(tags map[string]string) bool {
+	firstSeen, err := time.Parse(time.RFC3339, tags[tagFirstSeen])
+	if err != nil {
+		return false
+	}
+
+	return time.Now().UTC().Sub(firstSeen) >= gracePeriod
+}
+
+// tagFirstSeen tags the given EC2 resource with the current time, so that we
+// can tell its age in later runs.
+func (a *Cleaner) tagFirstSeen(id string) error {
+	i := &ec2.CreateTagsInput{
+		Resources: []*string{
+			aws.String(id),
+		},
+		Tags: []*ec2.Tag{
+			{
+				Key:   aws.String(tagFirstSeen),
+				Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+			},
+		},
+	}
+	_, err := a.ec2Client.CreateTags(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ec2Tags converts the given EC2 tags into a map of tag keys and values.
+func ec2Tags(tags []*ec2.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		m[*t.Key] = *t.Value
+	}
+
+	return m
+}
+
 func isTenantStack(stack *cloudformation.Stack) bool {
 	outputs := stack.Outputs
 	for _, o := range outputs {
@@ -300,6 +675,11 @@ func bucketShouldBeDeleted(bucket *s3.Bucket) bool {
 		}
 	}
 
+	// Terraform state buckets of e2e tests.
+	if isTerraformCIResource(*bucket.Name) {
+		return true
+	}
+
 	return false
 }
 
@@ -344,6 +724,51 @@ func (a *Cleaner) deleteBucket(name *string) error {
 			break
 		}
 	}
+	// versioned buckets, e.g. Terraform state buckets, can only be deleted
+	// once all object versions and delete markers are gone as well.
+	for {
+		i := &s3.ListObjectVersionsInput{
+			Bucket: name,
+		}
+		o, err := a.s3Client.ListObjectVersions(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var objects []*s3.ObjectIdentifier
+		for _, v := range o.Versions {
+			objects = append(objects, &s3.ObjectIdentifier{
+				Key:       v.Key,
+				VersionId: v.VersionId,
+			})
+		}
+		for _, m := range o.DeleteMarkers {
+			objects = append(objects, &s3.ObjectIdentifier{
+				Key:       m.Key,
+				VersionId: m.VersionId,
+			})
+		}
+		if len(objects) == 0 {
+			break
+		}
+
+		di := &s3.DeleteObjectsInput{
+			Bucket: name,
+			Delete: &s3.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		}
+		_, err = a.s3Client.DeleteObjects(di)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+	}
+
 	deleteBucketInput := &s3.DeleteBucketInput{
 		Bucket: name,
 	}
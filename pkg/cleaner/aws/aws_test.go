@@ -258,6 +258,22 @@ func TestBucketShouldBeDeleted(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			description: "recent terraform state bucket should not be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("e2eterraform1a2b3-state"),
+				CreationDate: aws.Time(time.Now()),
+			},
+			expected: false,
+		},
+		{
+			description: "old terraform state bucket should be deleted",
+			bucket: &s3.Bucket{
+				Name:         aws.String("e2eterraform1a2b3-state"),
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			expected: true,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -270,3 +286,111 @@ func TestBucketShouldBeDeleted(t *testing.T) {
 		})
 	}
 }
+
+func TestCIClusterFromTags(t *testing.T) {
+	tcs := []struct {
+		tags        map[string]string
+		expected    string
+		description string
+	}{
+		{
+			description: "no tags do not refer to a ci cluster",
+			tags:        map[string]string{},
+			expected:    "",
+		},
+		{
+			description: "cluster tag refers to a ci cluster",
+			tags: map[string]string{
+				"giantswarm.io/cluster": "ci-wip-50a83-d4f51",
+			},
+			expected: "ci-wip-50a83-d4f51",
+		},
+		{
+			description: "cluster tag refers to another cluster",
+			tags: map[string]string{
+				"giantswarm.io/cluster": "8y5ck",
+			},
+			expected: "",
+		},
+		{
+			description: "stack tag refers to a ci stack",
+			tags: map[string]string{
+				"aws:cloudformation:stack-name": "cluster-ci-cur-50a83-d4f51-guest-main",
+			},
+			expected: "cluster-ci-cur-50a83-d4f51-guest-main",
+		},
+		{
+			description: "kubernetes cluster tag refers to a ci cluster",
+			tags: map[string]string{
+				"kubernetes.io/cluster/ci-cur-50a83-d4f51": "owned",
+			},
+			expected: "ci-cur-50a83-d4f51",
+		},
+		{
+			description: "kubernetes cluster tag refers to another cluster",
+			tags: map[string]string{
+				"kubernetes.io/cluster/8y5ck": "owned",
+			},
+			expected: "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := ciClusterFromTags(tc.tags)
+
+			if actual != tc.expected {
+				t.Errorf("checking ci cluster of %v, want %q, got %q", tc.tags, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCIClusterID(t *testing.T) {
+	tcs := []struct {
+		name        string
+		expected    string
+		description string
+	}{
+		{
+			description: "cluster id refers to a ci cluster",
+			name:        "ci-1a2b3",
+			expected:    "ci-1a2b3",
+		},
+		{
+			description: "name derived from cluster id refers to a ci cluster",
+			name:        "ci-wip-1a2b3-dns-handler",
+			expected:    "ci-wip-1a2b3",
+		},
+		{
+			description: "stack name refers to a ci cluster",
+			name:        "cluster-ci-cur-50a83-d4f51-guest-main",
+			expected:    "ci-cur-50a83-d4f51",
+		},
+		{
+			description: "ci tooling does not refer to a ci cluster",
+			name:        "ci-cleaner",
+			expected:    "",
+		},
+		{
+			description: "e2e tooling does not refer to a ci cluster",
+			name:        "e2e-harness",
+			expected:    "",
+		},
+		{
+			description: "other cluster id does not refer to a ci cluster",
+			name:        "8y5ck",
+			expected:    "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := ciClusterID(tc.name)
+
+			if actual != tc.expected {
+				t.Errorf("checking ci cluster of %q, want %q, got %q", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
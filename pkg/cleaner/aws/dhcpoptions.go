@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanDHCPOptionSets deletes the custom DHCP option sets of CI clusters.
+// Option sets can only be deleted once no VPC is associated with them anymore,
+// so this has to run after the VPCs are cleaned.
+func (a *Cleaner) cleanDHCPOptionSets() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.describeVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	associated := map[string]bool{}
+	for _, vpc := range vpcs {
+		associated[aws.StringValue(vpc.DhcpOptionsId)] = true
+	}
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeDhcpOptionsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeDhcpOptions(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, options := range o.DhcpOptions {
+			id := *options.DhcpOptionsId
+
+			// option sets do not have a creation time, so we remember the time
+			// we saw them first.
+			if isCITagged(ec2Tags(options.Tags)) {
+				if _, ok := ec2Tags(options.Tags)[tagFirstSeen]; !ok {
+					err := a.tagFirstSeen(id)
+					if err != nil {
+						errors.Append(microerror.Mask(err))
+						a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging dhcp option set %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+					}
+					continue
+				}
+			}
+
+			if !dhcpOptionsShouldBeDeleted(options, associated) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that dhcp option set %#q should be deleted", id))
+
+			i := &ec2.DeleteDhcpOptionsInput{
+				DhcpOptionsId: options.DhcpOptionsId,
+			}
+			_, err := a.ec2Client.DeleteDhcpOptions(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("dhcp option set %#q does not exist anymore", id))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting dhcp option set %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted dhcp option set %#q", id))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func dhcpOptionsShouldBeDeleted(options *ec2.DhcpOptions, associated map[string]bool) bool {
+	tags := ec2Tags(options.Tags)
+
+	if !isCITagged(tags) {
+		return false
+	}
+
+	// do not delete option sets which are still used by a VPC.
+	if associated[aws.StringValue(options.DhcpOptionsId)] {
+		return false
+	}
+
+	return isFirstSeenBeforeGracePeriod(tags)
+}
@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestDHCPOptionsShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		options     *ec2.DhcpOptions
+		expected    bool
+		description string
+	}{
+		{
+			description: "unassociated ci option set first seen long ago should be deleted",
+			options: &ec2.DhcpOptions{
+				DhcpOptionsId: aws.String("dopt-1a2b3c4d"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "associated ci option set should not be deleted",
+			options: &ec2.DhcpOptions{
+				DhcpOptionsId: aws.String("dopt-5e6f7a8b"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "ci option set first seen recently should not be deleted",
+			options: &ec2.DhcpOptions{
+				DhcpOptionsId: aws.String("dopt-1a2b3c4d"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "non ci option set should not be deleted",
+			options: &ec2.DhcpOptions{
+				DhcpOptionsId: aws.String("dopt-1a2b3c4d"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	associated := map[string]bool{
+		"dopt-5e6f7a8b": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := dhcpOptionsShouldBeDeleted(tc.options, associated)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.options.DhcpOptionsId, tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanDynamoDBTables deletes the DynamoDB tables of CI tests, e.g. the state
+// lock tables of the Terraform e2e tests. Deletion protection is disabled
+// first in case it is enabled.
+func (a *Cleaner) cleanDynamoDBTables() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var exclusiveStartTableName *string
+	for {
+		i := &dynamodb.ListTablesInput{
+			ExclusiveStartTableName: exclusiveStartTableName,
+		}
+
+		o, err := a.dynamoDBClient.ListTables(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, name := range o.TableNames {
+			var table *dynamodb.TableDescription
+			{
+				i := &dynamodb.DescribeTableInput{
+					TableName: name,
+				}
+				o, err := a.dynamoDBClient.DescribeTable(i)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed describing dynamodb table %#q: %#v", *name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+
+				table = o.Table
+			}
+
+			// tables are listed without their tags, so we only fetch them for
+			// tables which are not recognized by name.
+			var tags map[string]string
+			if !isCIDynamoDBTableName(*name) {
+				tags, err = a.dynamoDBTableTags(table.TableArn)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of dynamodb table %#q: %#v", *name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+			}
+
+			if !dynamoDBTableShouldBeDeleted(table, tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that dynamodb table %#q should be deleted", *name))
+
+			err := a.deleteDynamoDBTable(table)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("dynamodb table %#q does not exist anymore", *name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting dynamodb table %#q: %#v", *name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted dynamodb table %#q", *name))
+			}
+		}
+
+		if o.LastEvaluatedTableName == nil {
+			break
+		}
+		exclusiveStartTableName = o.LastEvaluatedTableName
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteDynamoDBTable(table *dynamodb.TableDescription) error {
+	if aws.BoolValue(table.DeletionProtectionEnabled) {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("disabling deletion protection of dynamodb table %#q", *table.TableName))
+
+		i := &dynamodb.UpdateTableInput{
+			DeletionProtectionEnabled: aws.Bool(false),
+			TableName:                 table.TableName,
+		}
+		_, err := a.dynamoDBClient.UpdateTable(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// tables can only be deleted once they are active again.
+		{
+			i := &dynamodb.DescribeTableInput{
+				TableName: table.TableName,
+			}
+			err := a.dynamoDBClient.WaitUntilTableExists(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		i := &dynamodb.DeleteTableInput{
+			TableName: table.TableName,
+		}
+		_, err := a.dynamoDBClient.DeleteTable(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) dynamoDBTableTags(arn *string) (map[string]string, error) {
+	tags := map[string]string{}
+
+	var nextToken *string
+	for {
+		i := &dynamodb.ListTagsOfResourceInput{
+			NextToken:   nextToken,
+			ResourceArn: arn,
+		}
+
+		o, err := a.dynamoDBClient.ListTagsOfResource(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, t := range o.Tags {
+			if t.Key == nil || t.Value == nil {
+				continue
+			}
+			tags[*t.Key] = *t.Value
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return tags, nil
+}
+
+func isCIDynamoDBTableName(name string) bool {
+	return isCIResource(name) || isTerraformCIResource(name)
+}
+
+func dynamoDBTableShouldBeDeleted(table *dynamodb.TableDescription, tags map[string]string) bool {
+	if !isCIDynamoDBTableName(aws.StringValue(table.TableName)) && !isCITagged(tags) {
+		return false
+	}
+
+	// do not delete tables which are already being deleted or are changing.
+	switch aws.StringValue(table.TableStatus) {
+	case dynamodb.TableStatusCreating, dynamodb.TableStatusUpdating, dynamodb.TableStatusDeleting:
+		return false
+	}
+
+	if table.CreationDateTime == nil {
+		// bad formed table, should be deleted
+		return true
+	}
+
+	// do not delete recent tables.
+	if time.Now().UTC().Sub(*table.CreationDateTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
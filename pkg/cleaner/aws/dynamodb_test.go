@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestDynamoDBTableShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		table       *dynamodb.TableDescription
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old terraform lock table should be deleted",
+			table: &dynamodb.TableDescription{
+				CreationDateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				TableName:        aws.String("e2eterraform1a2b3-lock"),
+				TableStatus:      aws.String(dynamodb.TableStatusActive),
+			},
+			expected: true,
+		},
+		{
+			description: "old protected ci table should be deleted",
+			table: &dynamodb.TableDescription{
+				CreationDateTime:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				DeletionProtectionEnabled: aws.Bool(true),
+				TableName:                 aws.String("ci-wip-1a2b3-lock"),
+				TableStatus:               aws.String(dynamodb.TableStatusActive),
+			},
+			expected: true,
+		},
+		{
+			description: "old table tagged for ci cluster should be deleted",
+			table: &dynamodb.TableDescription{
+				CreationDateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				TableName:        aws.String("terraform-lock"),
+				TableStatus:      aws.String(dynamodb.TableStatusActive),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recent terraform lock table should not be deleted",
+			table: &dynamodb.TableDescription{
+				CreationDateTime: aws.Time(time.Now()),
+				TableName:        aws.String("e2eterraform1a2b3-lock"),
+				TableStatus:      aws.String(dynamodb.TableStatusActive),
+			},
+			expected: false,
+		},
+		{
+			description: "old terraform lock table being deleted should not be deleted",
+			table: &dynamodb.TableDescription{
+				CreationDateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				TableName:        aws.String("e2eterraform1a2b3-lock"),
+				TableStatus:      aws.String(dynamodb.TableStatusDeleting),
+			},
+			expected: false,
+		},
+		{
+			description: "old other table should not be deleted",
+			table: &dynamodb.TableDescription{
+				CreationDateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				TableName:        aws.String("terraform-lock"),
+				TableStatus:      aws.String(dynamodb.TableStatusActive),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := dynamoDBTableShouldBeDeleted(tc.table, tc.tags)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.table.TableName, tc.expected, actual)
+			}
+		})
+	}
+}
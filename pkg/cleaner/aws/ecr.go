@@ -0,0 +1,248 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// maxBatchDeleteImages is the maximum number of images which can be
+	// deleted in a single request.
+	maxBatchDeleteImages = 100
+)
+
+// cleanECRRepositories deletes the ECR repositories created per CI branch or
+// run together with all their images. From the configured shared repositories
+// the images pushed with CI tags are deleted once they are older than the
+// configured image tag grace period. All other repositories are not touched.
+func (a *Cleaner) cleanECRRepositories() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &ecr.DescribeRepositoriesInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ecrClient.DescribeRepositories(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, repository := range o.Repositories {
+			name := *repository.RepositoryName
+
+			if isSharedRepository(repository, a.ecrSharedRepositories) {
+				err := a.deleteCIImageTags(repository)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					// do not return on error, try to continue deleting.
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting ci image tags of ecr repository %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+				}
+				continue
+			}
+
+			if !ecrRepositoryShouldBeDeleted(repository, a.ecrSharedRepositories) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that ecr repository %#q should be deleted", name))
+
+			i := &ecr.DeleteRepositoryInput{
+				// the repository still contains images, which are deleted
+				// together with it.
+				Force:          aws.Bool(true),
+				RegistryId:     repository.RegistryId,
+				RepositoryName: repository.RepositoryName,
+			}
+			_, err := a.ecrClient.DeleteRepository(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("ecr repository %#q does not exist anymore", name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting ecr repository %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted ecr repository %#q", name))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// deleteCIImageTags deletes the CI tags of images in the given repository
+// which are older than the configured image tag grace period. Images only
+// tagged with CI tags are deleted altogether, otherwise only their CI tags are
+// removed.
+func (a *Cleaner) deleteCIImageTags(repository *ecr.Repository) error {
+	var ids []*ecr.ImageIdentifier
+	{
+		var nextToken *string
+		for {
+			i := &ecr.DescribeImagesInput{
+				Filter: &ecr.DescribeImagesFilter{
+					TagStatus: aws.String(ecr.TagStatusTagged),
+				},
+				NextToken:      nextToken,
+				RegistryId:     repository.RegistryId,
+				RepositoryName: repository.RepositoryName,
+			}
+
+			o, err := a.ecrClient.DescribeImages(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, image := range o.ImageDetails {
+				ids = append(ids, ciImageIdentifiers(image, a.ecrImageTagGracePeriod)...)
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxBatchDeleteImages {
+			n = maxBatchDeleteImages
+		}
+
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting %d ci images of ecr repository %#q", n, *repository.RepositoryName))
+
+		i := &ecr.BatchDeleteImageInput{
+			ImageIds:       ids[:n],
+			RegistryId:     repository.RegistryId,
+			RepositoryName: repository.RepositoryName,
+		}
+		o, err := a.ecrClient.BatchDeleteImage(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, failure := range o.Failures {
+			// images may be gone already, e.g. because another tag of the
+			// same image was deleted in the same request.
+			if aws.StringValue(failure.FailureCode) == ecr.ImageFailureCodeImageNotFound || aws.StringValue(failure.FailureCode) == ecr.ImageFailureCodeImageTagDoesNotMatchDigest {
+				continue
+			}
+
+			return microerror.Maskf(executionFailedError, "failed deleting image %s: %s", imageIdentifierString(failure.ImageId), aws.StringValue(failure.FailureReason))
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("deleted %d ci images of ecr repository %#q", len(o.ImageIds), *repository.RepositoryName))
+
+		ids = ids[n:]
+	}
+
+	return nil
+}
+
+// isCIRepository returns true if the given repository was created per CI
+// branch or run. Its name starts with a CI cluster ID, optionally namespaced,
+// e.g. giantswarm/ci-wip-1a2b3, so that repositories of CI tooling like
+// giantswarm/ci-cleaner are not matched. Shared repositories are never CI
+// repositories.
+func isCIRepository(repository *ecr.Repository, shared []string) bool {
+	if isSharedRepository(repository, shared) {
+		return false
+	}
+
+	return ciClusterFromPath(aws.StringValue(repository.RepositoryName)) != ""
+}
+
+func isSharedRepository(repository *ecr.Repository, shared []string) bool {
+	for _, name := range shared {
+		if aws.StringValue(repository.RepositoryName) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ecrRepositoryShouldBeDeleted(repository *ecr.Repository, shared []string) bool {
+	if !isCIRepository(repository, shared) {
+		return false
+	}
+
+	if repository.CreatedAt == nil {
+		// bad formed repository, should be deleted
+		return true
+	}
+
+	// do not delete recent repositories.
+	if time.Now().UTC().Sub(*repository.CreatedAt) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// ciImageIdentifiers returns the identifiers needed to delete the CI tags of
+// the given image, in case it is older than tagGracePeriod. Images only
+// tagged with CI tags are identified by their digest, so that they are deleted
+// altogether and do not remain untagged.
+func ciImageIdentifiers(image *ecr.ImageDetail, tagGracePeriod time.Duration) []*ecr.ImageIdentifier {
+	// do not delete recent images. Images are pulled again by later pipeline
+	// steps and reruns, so they may be kept longer than other CI resources.
+	if image.ImagePushedAt == nil || time.Now().UTC().Sub(*image.ImagePushedAt) < tagGracePeriod {
+		return nil
+	}
+
+	var ciTags []*string
+	for _, tag := range image.ImageTags {
+		if isCIResource(aws.StringValue(tag)) {
+			ciTags = append(ciTags, tag)
+		}
+	}
+
+	if len(ciTags) == 0 {
+		return nil
+	}
+
+	if len(ciTags) == len(image.ImageTags) {
+		return []*ecr.ImageIdentifier{
+			{
+				ImageDigest: image.ImageDigest,
+			},
+		}
+	}
+
+	var ids []*ecr.ImageIdentifier
+	for _, tag := range ciTags {
+		ids = append(ids, &ecr.ImageIdentifier{
+			ImageTag: tag,
+		})
+	}
+
+	return ids
+}
+
+func imageIdentifierString(id *ecr.ImageIdentifier) string {
+	if id == nil {
+		return ""
+	}
+	if id.ImageTag != nil {
+		return fmt.Sprintf("%#q", *id.ImageTag)
+	}
+
+	return fmt.Sprintf("%#q", aws.StringValue(id.ImageDigest))
+}
@@ -0,0 +1,185 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+func TestECRRepositoryShouldBeDeleted(t *testing.T) {
+	shared := []string{"giantswarm/ci-1a2b3-images"}
+
+	tcs := []struct {
+		repository  *ecr.Repository
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci repository should be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now().Add(-2 * time.Hour)),
+				RepositoryName: aws.String("ci-wip-1a2b3"),
+			},
+			expected: true,
+		},
+		{
+			description: "old namespaced ci repository should be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now().Add(-2 * time.Hour)),
+				RepositoryName: aws.String("giantswarm/ci-wip-1a2b3"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci repository should not be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now()),
+				RepositoryName: aws.String("ci-wip-1a2b3"),
+			},
+			expected: false,
+		},
+		{
+			description: "old shared repository should not be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now().Add(-2 * time.Hour)),
+				RepositoryName: aws.String("giantswarm/aws-operator"),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci tooling repository should not be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now().Add(-2 * time.Hour)),
+				RepositoryName: aws.String("giantswarm/ci-cleaner"),
+			},
+			expected: false,
+		},
+		{
+			description: "old e2e tooling repository should not be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now().Add(-2 * time.Hour)),
+				RepositoryName: aws.String("giantswarm/e2e-harness"),
+			},
+			expected: false,
+		},
+		{
+			description: "old configured shared repository should not be deleted",
+			repository: &ecr.Repository{
+				CreatedAt:      aws.Time(time.Now().Add(-2 * time.Hour)),
+				RepositoryName: aws.String("giantswarm/ci-1a2b3-images"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := ecrRepositoryShouldBeDeleted(tc.repository, shared)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.repository.RepositoryName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCIImageIdentifiers(t *testing.T) {
+	tcs := []struct {
+		image       *ecr.ImageDetail
+		expected    []*ecr.ImageIdentifier
+		description string
+	}{
+		{
+			description: "old image only tagged with ci tags should be deleted by digest",
+			image: &ecr.ImageDetail{
+				ImageDigest:   aws.String("sha256:1a2b3"),
+				ImagePushedAt: aws.Time(time.Now().Add(-48 * time.Hour)),
+				ImageTags:     aws.StringSlice([]string{"ci-wip-1a2b3", "e2e-1a2b3"}),
+			},
+			expected: []*ecr.ImageIdentifier{
+				{
+					ImageDigest: aws.String("sha256:1a2b3"),
+				},
+			},
+		},
+		{
+			description: "old image with release tag should only have its ci tags deleted",
+			image: &ecr.ImageDetail{
+				ImageDigest:   aws.String("sha256:1a2b3"),
+				ImagePushedAt: aws.Time(time.Now().Add(-48 * time.Hour)),
+				ImageTags:     aws.StringSlice([]string{"ci-wip-1a2b3", "v1.2.3"}),
+			},
+			expected: []*ecr.ImageIdentifier{
+				{
+					ImageTag: aws.String("ci-wip-1a2b3"),
+				},
+			},
+		},
+		{
+			description: "recent image with ci tags should not be deleted",
+			image: &ecr.ImageDetail{
+				ImageDigest:   aws.String("sha256:1a2b3"),
+				ImagePushedAt: aws.Time(time.Now().Add(-2 * time.Hour)),
+				ImageTags:     aws.StringSlice([]string{"ci-wip-1a2b3"}),
+			},
+			expected: nil,
+		},
+		{
+			description: "old image without ci tags should not be deleted",
+			image: &ecr.ImageDetail{
+				ImageDigest:   aws.String("sha256:1a2b3"),
+				ImagePushedAt: aws.Time(time.Now().Add(-48 * time.Hour)),
+				ImageTags:     aws.StringSlice([]string{"latest", "v1.2.3"}),
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := ciImageIdentifiers(tc.image, 24*time.Hour)
+
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("checking which tags of %q should be deleted, want %v, got %v", *tc.image.ImageDigest, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsSharedRepository(t *testing.T) {
+	shared := []string{"giantswarm/aws-operator"}
+
+	tcs := []struct {
+		repository  *ecr.Repository
+		expected    bool
+		description string
+	}{
+		{
+			description: "configured repository should be shared",
+			repository: &ecr.Repository{
+				RepositoryName: aws.String("giantswarm/aws-operator"),
+			},
+			expected: true,
+		},
+		{
+			description: "other repository should not be shared",
+			repository: &ecr.Repository{
+				RepositoryName: aws.String("giantswarm/aws-operator-test"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := isSharedRepository(tc.repository, shared)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q is shared, want %t, got %t", *tc.repository.RepositoryName, tc.expected, actual)
+			}
+		})
+	}
+}
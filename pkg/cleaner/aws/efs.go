@@ -0,0 +1,231 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanEFSFileSystems deletes the EFS file systems of CI clusters, e.g. created
+// by storage tests. File systems can only be deleted once their mount targets
+// are gone, and their mount targets block the deletion of the subnets of CI
+// VPCs, so this has to run before the VPCs are cleaned. Access points are
+// deleted first, since they would keep the file system in use.
+func (a *Cleaner) cleanEFSFileSystems() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var marker *string
+	for {
+		i := &efs.DescribeFileSystemsInput{
+			Marker: marker,
+		}
+
+		o, err := a.efsClient.DescribeFileSystems(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, fs := range o.FileSystems {
+			id := *fs.FileSystemId
+
+			mountTargets, err := a.efsMountTargets(fs.FileSystemId)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed describing mount targets of efs file system %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			if !efsFileSystemShouldBeDeleted(fs, mountTargets, vpcs) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that efs file system %#q should be deleted", id))
+
+			err = a.deleteEFSFileSystem(fs.FileSystemId, mountTargets)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("efs file system %#q does not exist anymore", id))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting efs file system %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted efs file system %#q", id))
+			}
+		}
+
+		if o.NextMarker == nil {
+			break
+		}
+		marker = o.NextMarker
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteEFSFileSystem(id *string, mountTargets []*efs.MountTargetDescription) error {
+	{
+		var nextToken *string
+		for {
+			i := &efs.DescribeAccessPointsInput{
+				FileSystemId: id,
+				NextToken:    nextToken,
+			}
+
+			o, err := a.efsClient.DescribeAccessPoints(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, accessPoint := range o.AccessPoints {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting access point %#q of efs file system %#q", *accessPoint.AccessPointId, *id))
+
+				i := &efs.DeleteAccessPointInput{
+					AccessPointId: accessPoint.AccessPointId,
+				}
+				_, err := a.efsClient.DeleteAccessPoint(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	for _, mountTarget := range mountTargets {
+		// mount targets being deleted are waited for below.
+		if aws.StringValue(mountTarget.LifeCycleState) == efs.LifeCycleStateDeleting {
+			continue
+		}
+
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting mount target %#q of efs file system %#q", *mountTarget.MountTargetId, *id))
+
+		i := &efs.DeleteMountTargetInput{
+			MountTargetId: mountTarget.MountTargetId,
+		}
+		_, err := a.efsClient.DeleteMountTarget(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if len(mountTargets) > 0 {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for mount targets of efs file system %#q to be deleted", *id))
+
+		err := waitFor(func() (bool, error) {
+			mountTargets, err := a.efsMountTargets(id)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			return len(mountTargets) == 0, nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &efs.DeleteFileSystemInput{
+			FileSystemId: id,
+		}
+		_, err := a.efsClient.DeleteFileSystem(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) efsMountTargets(id *string) ([]*efs.MountTargetDescription, error) {
+	var mountTargets []*efs.MountTargetDescription
+
+	var marker *string
+	for {
+		i := &efs.DescribeMountTargetsInput{
+			FileSystemId: id,
+			Marker:       marker,
+		}
+
+		o, err := a.efsClient.DescribeMountTargets(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		mountTargets = append(mountTargets, o.MountTargets...)
+
+		if o.NextMarker == nil {
+			break
+		}
+		marker = o.NextMarker
+	}
+
+	return mountTargets, nil
+}
+
+func efsFileSystemShouldBeDeleted(fs *efs.FileSystemDescription, mountTargets []*efs.MountTargetDescription, vpcs map[string]bool) bool {
+	// do not delete file systems that are already being deleted.
+	switch aws.StringValue(fs.LifeCycleState) {
+	case efs.LifeCycleStateDeleting, efs.LifeCycleStateDeleted:
+		return false
+	}
+
+	isCI := isCITagged(efsTags(fs.Tags))
+	for _, mountTarget := range mountTargets {
+		if vpcs[aws.StringValue(mountTarget.VpcId)] {
+			isCI = true
+		}
+	}
+	if !isCI {
+		return false
+	}
+
+	if fs.CreationTime == nil {
+		// bad formed file system, should be deleted
+		return true
+	}
+
+	// do not delete recent file systems.
+	if time.Now().UTC().Sub(*fs.CreationTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// efsTags converts the given EFS tags into a map of tag keys and values.
+func efsTags(tags []*efs.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		m[*t.Key] = *t.Value
+	}
+
+	return m
+}
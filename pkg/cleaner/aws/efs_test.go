@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+func TestEFSFileSystemShouldBeDeleted(t *testing.T) {
+	vpcs := map[string]bool{
+		"vpc-1": true,
+	}
+
+	tcs := []struct {
+		fs           *efs.FileSystemDescription
+		mountTargets []*efs.MountTargetDescription
+		expected     bool
+		description  string
+	}{
+		{
+			description: "old file system tagged for ci cluster should be deleted",
+			fs: &efs.FileSystemDescription{
+				CreationTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				FileSystemId:   aws.String("fs-1"),
+				LifeCycleState: aws.String(efs.LifeCycleStateAvailable),
+				Tags: []*efs.Tag{
+					{
+						Key:   aws.String(tagName),
+						Value: aws.String("ci-wip-1a2b3-storage"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old file system with mount target in ci vpc should be deleted",
+			fs: &efs.FileSystemDescription{
+				CreationTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				FileSystemId:   aws.String("fs-1"),
+				LifeCycleState: aws.String(efs.LifeCycleStateAvailable),
+			},
+			mountTargets: []*efs.MountTargetDescription{
+				{
+					MountTargetId: aws.String("fsmt-1"),
+					VpcId:         aws.String("vpc-1"),
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent file system with mount target in ci vpc should not be deleted",
+			fs: &efs.FileSystemDescription{
+				CreationTime:   aws.Time(time.Now()),
+				FileSystemId:   aws.String("fs-1"),
+				LifeCycleState: aws.String(efs.LifeCycleStateAvailable),
+			},
+			mountTargets: []*efs.MountTargetDescription{
+				{
+					MountTargetId: aws.String("fsmt-1"),
+					VpcId:         aws.String("vpc-1"),
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old ci file system being deleted should not be deleted",
+			fs: &efs.FileSystemDescription{
+				CreationTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				FileSystemId:   aws.String("fs-1"),
+				LifeCycleState: aws.String(efs.LifeCycleStateDeleting),
+				Tags: []*efs.Tag{
+					{
+						Key:   aws.String(tagName),
+						Value: aws.String("ci-wip-1a2b3-storage"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old file system with mount target in other vpc should not be deleted",
+			fs: &efs.FileSystemDescription{
+				CreationTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				FileSystemId:   aws.String("fs-1"),
+				LifeCycleState: aws.String(efs.LifeCycleStateAvailable),
+			},
+			mountTargets: []*efs.MountTargetDescription{
+				{
+					MountTargetId: aws.String("fsmt-1"),
+					VpcId:         aws.String("vpc-2"),
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := efsFileSystemShouldBeDeleted(tc.fs, tc.mountTargets, vpcs)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.fs.FileSystemId, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanEKSClusters deletes EKS clusters of CI tests, e.g. leftover by failed
+// eksctl or cluster-api tests. EKS clusters can only be deleted once their
+// addons, Fargate profiles and managed nodegroups are gone, so these are
+// deleted first and waited for. We wait for the clusters to be deleted as
+// well, since their network interfaces block the deletion of their VPCs.
+func (a *Cleaner) cleanEKSClusters() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &eks.ListClustersInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.eksClient.ListClusters(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, name := range o.Clusters {
+			var cluster *eks.Cluster
+			{
+				i := &eks.DescribeClusterInput{
+					Name: name,
+				}
+				o, err := a.eksClient.DescribeCluster(i)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed describing eks cluster %#q: %#v", *name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+
+				cluster = o.Cluster
+			}
+
+			if !eksClusterShouldBeDeleted(cluster) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that eks cluster %#q should be deleted", *name))
+
+			err := a.deleteEKSCluster(cluster)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("eks cluster %#q does not exist anymore", *name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting eks cluster %#q: %#v", *name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted eks cluster %#q", *name))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteEKSCluster(cluster *eks.Cluster) error {
+	{
+		addons, err := a.eksAddons(cluster.Name)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, addon := range addons {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting addon %#q of eks cluster %#q", *addon, *cluster.Name))
+
+			i := &eks.DeleteAddonInput{
+				AddonName:   addon,
+				ClusterName: cluster.Name,
+			}
+			_, err := a.eksClient.DeleteAddon(i)
+			if IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		for _, addon := range addons {
+			i := &eks.DescribeAddonInput{
+				AddonName:   addon,
+				ClusterName: cluster.Name,
+			}
+			err := a.eksClient.WaitUntilAddonDeleted(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		profiles, err := a.eksFargateProfiles(cluster.Name)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// only one Fargate profile of a cluster can be deleted at a time, so
+		// we wait for each of them before deleting the next one.
+		for _, profile := range profiles {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting fargate profile %#q of eks cluster %#q", *profile, *cluster.Name))
+
+			i := &eks.DeleteFargateProfileInput{
+				ClusterName:        cluster.Name,
+				FargateProfileName: profile,
+			}
+			_, err := a.eksClient.DeleteFargateProfile(i)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+
+			{
+				i := &eks.DescribeFargateProfileInput{
+					ClusterName:        cluster.Name,
+					FargateProfileName: profile,
+				}
+				err := a.eksClient.WaitUntilFargateProfileDeleted(i)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+		}
+	}
+
+	{
+		nodegroups, err := a.eksNodegroups(cluster.Name)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, nodegroup := range nodegroups {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting nodegroup %#q of eks cluster %#q", *nodegroup, *cluster.Name))
+
+			i := &eks.DeleteNodegroupInput{
+				ClusterName:   cluster.Name,
+				NodegroupName: nodegroup,
+			}
+			_, err := a.eksClient.DeleteNodegroup(i)
+			if IsNotFound(err) || IsInUse(err) {
+				// fall through, the nodegroup is already being deleted.
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		for _, nodegroup := range nodegroups {
+			i := &eks.DescribeNodegroupInput{
+				ClusterName:   cluster.Name,
+				NodegroupName: nodegroup,
+			}
+			err := a.eksClient.WaitUntilNodegroupDeleted(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	{
+		i := &eks.DeleteClusterInput{
+			Name: cluster.Name,
+		}
+		_, err := a.eksClient.DeleteCluster(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &eks.DescribeClusterInput{
+			Name: cluster.Name,
+		}
+		err := a.eksClient.WaitUntilClusterDeleted(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) eksAddons(cluster *string) ([]*string, error) {
+	var addons []*string
+
+	var nextToken *string
+	for {
+		i := &eks.ListAddonsInput{
+			ClusterName: cluster,
+			NextToken:   nextToken,
+		}
+
+		o, err := a.eksClient.ListAddons(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		addons = append(addons, o.Addons...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return addons, nil
+}
+
+func (a *Cleaner) eksFargateProfiles(cluster *string) ([]*string, error) {
+	var profiles []*string
+
+	var nextToken *string
+	for {
+		i := &eks.ListFargateProfilesInput{
+			ClusterName: cluster,
+			NextToken:   nextToken,
+		}
+
+		o, err := a.eksClient.ListFargateProfiles(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		profiles = append(profiles, o.FargateProfileNames...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return profiles, nil
+}
+
+func (a *Cleaner) eksNodegroups(cluster *string) ([]*string, error) {
+	var nodegroups []*string
+
+	var nextToken *string
+	for {
+		i := &eks.ListNodegroupsInput{
+			ClusterName: cluster,
+			NextToken:   nextToken,
+		}
+
+		o, err := a.eksClient.ListNodegroups(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		nodegroups = append(nodegroups, o.Nodegroups...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return nodegroups, nil
+}
+
+func eksClusterShouldBeDeleted(cluster *eks.Cluster) bool {
+	if !isCIResource(aws.StringValue(cluster.Name)) && !isCITagged(eksTags(cluster.Tags)) {
+		return false
+	}
+
+	// do not delete clusters that are already being deleted.
+	if aws.StringValue(cluster.Status) == eks.ClusterStatusDeleting {
+		return false
+	}
+
+	if cluster.CreatedAt == nil {
+		// bad formed cluster, should be deleted
+		return true
+	}
+
+	// do not delete recent clusters.
+	if time.Now().UTC().Sub(*cluster.CreatedAt) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// eksTags converts the given EKS tags into a map of tag keys and values.
+func eksTags(tags map[string]*string) map[string]string {
+	m := map[string]string{}
+	for k, v := range tags {
+		if v == nil {
+			continue
+		}
+		m[k] = *v
+	}
+
+	return m
+}
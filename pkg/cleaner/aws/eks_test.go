@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+func TestEKSClusterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		cluster     *eks.Cluster
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci cluster should be deleted",
+			cluster: &eks.Cluster{
+				CreatedAt: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:      aws.String("ci-wip-1a2b3"),
+				Status:    aws.String(eks.ClusterStatusActive),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci cluster should not be deleted",
+			cluster: &eks.Cluster{
+				CreatedAt: aws.Time(time.Now()),
+				Name:      aws.String("ci-wip-1a2b3"),
+				Status:    aws.String(eks.ClusterStatusActive),
+			},
+			expected: false,
+		},
+		{
+			description: "old failed cluster tagged for ci cluster should be deleted",
+			cluster: &eks.Cluster{
+				CreatedAt: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:      aws.String("eks-test"),
+				Status:    aws.String(eks.ClusterStatusFailed),
+				Tags: map[string]*string{
+					tagCluster: aws.String("ci-wip-1a2b3"),
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old ci cluster being deleted should not be deleted",
+			cluster: &eks.Cluster{
+				CreatedAt: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:      aws.String("ci-wip-1a2b3"),
+				Status:    aws.String(eks.ClusterStatusDeleting),
+			},
+			expected: false,
+		},
+		{
+			description: "old other cluster should not be deleted",
+			cluster: &eks.Cluster{
+				CreatedAt: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:      aws.String("abc12"),
+				Status:    aws.String(eks.ClusterStatusActive),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := eksClusterShouldBeDeleted(tc.cluster)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.cluster.Name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanElasticIPs releases Elastic IPs allocated for CI clusters. Leftover
+// addresses cost money and quickly exhaust the per-region quota.
+func (a *Cleaner) cleanElasticIPs() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stacks, err := a.stacks()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	o, err := a.ec2Client.DescribeAddresses(&ec2.DescribeAddressesInput{})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, addr := range o.Addresses {
+		// addresses do not have a creation time, so we remember the time we
+		// saw them first in case they were not created by CloudFormation.
+		if isCIAddressWithoutStack(addr) && addr.AllocationId != nil {
+			if _, ok := ec2Tags(addr.Tags)[tagFirstSeen]; !ok {
+				err := a.tagFirstSeen(*addr.AllocationId)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging elastic ip %#q: %#v", *addr.PublicIp, err), "stack", fmt.Sprintf("%#v", err))
+				}
+				continue
+			}
+		}
+
+		if !addressShouldBeReleased(addr, stacks) {
+			continue
+		}
+
+		// Associated addresses are only released in case the instance or NAT
+		// gateway they are associated with does not exist anymore.
+		if addr.AssociationId != nil {
+			orphaned, err := a.isAddressOrphaned(addr)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue releasing.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed checking association of elastic ip %#q: %#v", *addr.PublicIp, err), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+			if !orphaned {
+				continue
+			}
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that elastic ip %#q should be released", *addr.PublicIp))
+
+		err := a.releaseAddress(addr)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue releasing.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed releasing elastic ip %#q: %#v", *addr.PublicIp, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("released elastic ip %#q", *addr.PublicIp))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// isAddressOrphaned returns true if the instance or NAT gateway the given
+// address is associated with does not exist anymore.
+func (a *Cleaner) isAddressOrphaned(addr *ec2.Address) (bool, error) {
+	if addr.InstanceId != nil {
+		i := &ec2.DescribeInstancesInput{
+			InstanceIds: []*string{
+				addr.InstanceId,
+			},
+		}
+		o, err := a.ec2Client.DescribeInstances(i)
+		if IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, reservation := range o.Reservations {
+			for _, instance := range reservation.Instances {
+				if aws.StringValue(instance.State.Name) != ec2.InstanceStateNameTerminated {
+					return false, nil
+				}
+			}
+		}
+
+		return true, nil
+	}
+
+	if addr.NetworkInterfaceId != nil {
+		i := &ec2.DescribeNetworkInterfacesInput{
+			NetworkInterfaceIds: []*string{
+				addr.NetworkInterfaceId,
+			},
+		}
+		o, err := a.ec2Client.DescribeNetworkInterfaces(i)
+		if IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, eni := range o.NetworkInterfaces {
+			if aws.StringValue(eni.InterfaceType) != ec2.NetworkInterfaceTypeNatGateway {
+				return false, nil
+			}
+
+			i := &ec2.DescribeNatGatewaysInput{
+				Filter: []*ec2.Filter{
+					{
+						Name: aws.String("subnet-id"),
+						Values: []*string{
+							eni.SubnetId,
+						},
+					},
+				},
+			}
+			o, err := a.ec2Client.DescribeNatGateways(i)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			for _, nat := range o.NatGateways {
+				for _, natAddr := range nat.NatGatewayAddresses {
+					if aws.StringValue(natAddr.NetworkInterfaceId) != *addr.NetworkInterfaceId {
+						continue
+					}
+
+					state := aws.StringValue(nat.State)
+					if state != ec2.NatGatewayStateDeleted && state != ec2.NatGatewayStateFailed {
+						return false, nil
+					}
+				}
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (a *Cleaner) releaseAddress(addr *ec2.Address) error {
+	// addresses associated with NAT gateways are disassociated by AWS once the
+	// NAT gateway is deleted.
+	if addr.AssociationId != nil && addr.InstanceId != nil {
+		i := &ec2.DisassociateAddressInput{
+			AssociationId: addr.AssociationId,
+		}
+		_, err := a.ec2Client.DisassociateAddress(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	i := &ec2.ReleaseAddressInput{
+		AllocationId: addr.AllocationId,
+	}
+	// addresses of EC2-Classic do not have an allocation ID.
+	if addr.AllocationId == nil {
+		i = &ec2.ReleaseAddressInput{
+			PublicIp: addr.PublicIp,
+		}
+	}
+	_, err := a.ec2Client.ReleaseAddress(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// isCIAddressWithoutStack returns true if the given address is tagged for a CI
+// cluster but was not created by CloudFormation, e.g. by cluster-api or
+// Terraform.
+func isCIAddressWithoutStack(addr *ec2.Address) bool {
+	tags := ec2Tags(addr.Tags)
+	_, ok := tags[tagCloudFormationStack]
+
+	return isCITagged(tags) && !ok
+}
+
+func addressShouldBeReleased(addr *ec2.Address, stacks map[string]*cloudformation.Stack) bool {
+	tags := ec2Tags(addr.Tags)
+
+	if !isCITagged(tags) {
+		return false
+	}
+
+	// addresses do not have a creation time. Do not release addresses which
+	// were seen first recently, they may be about to be associated.
+	if isCIAddressWithoutStack(addr) {
+		return isFirstSeenBeforeGracePeriod(tags)
+	}
+
+	// Do not release addresses of recent stacks, they may be about to be
+	// associated.
+	stack, ok := stacks[tags[tagCloudFormationStack]]
+	if ok && stack.CreationTime != nil && time.Now().UTC().Sub(*stack.CreationTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestAddressShouldBeReleased(t *testing.T) {
+	stacks := map[string]*cloudformation.Stack{
+		"cluster-ci-recent-guest-main": {
+			StackName:    aws.String("cluster-ci-recent-guest-main"),
+			CreationTime: aws.Time(time.Now()),
+		},
+		"cluster-ci-old-guest-main": {
+			StackName:    aws.String("cluster-ci-old-guest-main"),
+			CreationTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+		},
+	}
+
+	tcs := []struct {
+		addr        *ec2.Address
+		expected    bool
+		description string
+	}{
+		{
+			description: "untagged address should not be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.4"),
+			},
+			expected: false,
+		},
+		{
+			description: "address of other cluster should not be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.5"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("8y5ck"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "address of ci cluster first seen long ago should be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.6"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("ci-wip-50a83-d4f51"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "address of ci cluster first seen recently should not be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.9"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("kubernetes.io/cluster/ci-wip-50a83-d4f51"),
+						Value: aws.String("owned"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "address of ci cluster not seen before should not be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.10"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("ci-wip-50a83-d4f51"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "address of recent ci stack should not be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.7"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("aws:cloudformation:stack-name"),
+						Value: aws.String("cluster-ci-recent-guest-main"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "address of old ci stack should be released",
+			addr: &ec2.Address{
+				PublicIp: aws.String("1.2.3.8"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("aws:cloudformation:stack-name"),
+						Value: aws.String("cluster-ci-old-guest-main"),
+					},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := addressShouldBeReleased(tc.addr, stacks)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be released, want %t, got %t", *tc.addr.PublicIp, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanVPCEndpoints deletes VPC endpoints of CI VPCs or tagged for CI clusters.
+// Interface endpoints, e.g. for S3, ECR or STS, block the deletion of their
+// subnets and of the load balancers backing endpoint services.
+func (a *Cleaner) cleanVPCEndpoints() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	endpoints, err := a.ciVPCEndpoints()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var ids []*string
+	for _, endpoint := range endpoints {
+		if !vpcEndpointShouldBeDeleted(endpoint) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that vpc endpoint %#q should be deleted", *endpoint.VpcEndpointId))
+		ids = append(ids, endpoint.VpcEndpointId)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	i := &ec2.DeleteVpcEndpointsInput{
+		VpcEndpointIds: ids,
+	}
+	o, err := a.ec2Client.DeleteVpcEndpoints(i)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	failed := map[string]bool{}
+	for _, item := range o.Unsuccessful {
+		id := aws.StringValue(item.ResourceId)
+		if item.Error != nil && strings.HasSuffix(aws.StringValue(item.Error.Code), ".NotFound") {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("vpc endpoint %#q does not exist anymore", id))
+			continue
+		}
+
+		failed[id] = true
+		err := microerror.Maskf(executionFailedError, "%s", unsuccessfulItemMessage(item))
+		errors.Append(err)
+		// do not return on error, try to continue deleting.
+		a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpc endpoint %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	for _, id := range ids {
+		if !failed[*id] {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted vpc endpoint %#q", *id))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanVPCEndpointServices deletes endpoint service configurations of CI
+// clusters together with their endpoint connections. Pending connections of
+// CI endpoints to other services are rejected.
+func (a *Cleaner) cleanVPCEndpointServices() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	endpoints, err := a.ciVPCEndpoints()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	ciEndpoints := map[string]bool{}
+	for _, endpoint := range endpoints {
+		ciEndpoints[*endpoint.VpcEndpointId] = true
+	}
+
+	var services []*ec2.ServiceConfiguration
+	{
+		var nextToken *string
+		for {
+			i := &ec2.DescribeVpcEndpointServiceConfigurationsInput{
+				NextToken: nextToken,
+			}
+
+			o, err := a.ec2Client.DescribeVpcEndpointServiceConfigurations(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			services = append(services, o.ServiceConfigurations...)
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	for _, service := range services {
+		tags := ec2Tags(service.Tags)
+
+		// endpoint services do not have a creation time, so we remember the
+		// time we saw them first.
+		if isCIEndpointService(service) {
+			if _, ok := tags[tagFirstSeen]; !ok {
+				err := a.tagFirstSeen(*service.ServiceId)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging vpc endpoint service %#q: %#v", *service.ServiceId, err), "stack", fmt.Sprintf("%#v", err))
+				}
+			}
+		}
+
+		connections, err := a.vpcEndpointConnections(*service.ServiceId)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed describing connections of vpc endpoint service %#q: %#v", *service.ServiceId, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		if !endpointServiceShouldBeDeleted(service) {
+			var pending []*ec2.VpcEndpointConnection
+			for _, c := range connections {
+				if ciEndpoints[aws.StringValue(c.VpcEndpointId)] && vpcEndpointConnectionShouldBeRejected(c) {
+					pending = append(pending, c)
+				}
+			}
+
+			err := a.rejectVPCEndpointConnections(*service.ServiceId, pending)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed rejecting connections of vpc endpoint service %#q: %#v", *service.ServiceId, err), "stack", fmt.Sprintf("%#v", err))
+			}
+
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that vpc endpoint service %#q should be deleted", *service.ServiceId))
+
+		// services with connections cannot be deleted, so we reject all of
+		// them first.
+		var open []*ec2.VpcEndpointConnection
+		for _, c := range connections {
+			if !isVPCEndpointConnectionClosed(c) {
+				open = append(open, c)
+			}
+		}
+
+		err = a.rejectVPCEndpointConnections(*service.ServiceId, open)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed rejecting connections of vpc endpoint service %#q: %#v. Skipping deletion.", *service.ServiceId, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		i := &ec2.DeleteVpcEndpointServiceConfigurationsInput{
+			ServiceIds: []*string{
+				service.ServiceId,
+			},
+		}
+		o, err := a.ec2Client.DeleteVpcEndpointServiceConfigurations(i)
+		if err == nil && len(o.Unsuccessful) > 0 {
+			err = microerror.Maskf(executionFailedError, "%s", unsuccessfulItemMessage(o.Unsuccessful[0]))
+		}
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpc endpoint service %#q: %#v", *service.ServiceId, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted vpc endpoint service %#q", *service.ServiceId))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// ciVPCEndpoints returns all VPC endpoints of CI VPCs or tagged for CI
+// clusters.
+func (a *Cleaner) ciVPCEndpoints() ([]*ec2.VpcEndpoint, error) {
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var endpoints []*ec2.VpcEndpoint
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeVpcEndpointsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeVpcEndpoints(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, endpoint := range o.VpcEndpoints {
+			if vpcs[aws.StringValue(endpoint.VpcId)] || isCITagged(ec2Tags(endpoint.Tags)) {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return endpoints, nil
+}
+
+func (a *Cleaner) vpcEndpointConnections(serviceID string) ([]*ec2.VpcEndpointConnection, error) {
+	var connections []*ec2.VpcEndpointConnection
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeVpcEndpointConnectionsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name: aws.String("service-id"),
+					Values: []*string{
+						aws.String(serviceID),
+					},
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeVpcEndpointConnections(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		connections = append(connections, o.VpcEndpointConnections...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return connections, nil
+}
+
+func (a *Cleaner) rejectVPCEndpointConnections(serviceID string, connections []*ec2.VpcEndpointConnection) error {
+	if len(connections) == 0 {
+		return nil
+	}
+
+	var ids []*string
+	for _, c := range connections {
+		ids = append(ids, c.VpcEndpointId)
+	}
+
+	i := &ec2.RejectVpcEndpointConnectionsInput{
+		ServiceId:      aws.String(serviceID),
+		VpcEndpointIds: ids,
+	}
+	o, err := a.ec2Client.RejectVpcEndpointConnections(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if len(o.Unsuccessful) > 0 {
+		return microerror.Maskf(executionFailedError, "%s", unsuccessfulItemMessage(o.Unsuccessful[0]))
+	}
+
+	for _, id := range ids {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("rejected connection of vpc endpoint %#q to vpc endpoint service %#q", *id, serviceID))
+	}
+
+	return nil
+}
+
+func vpcEndpointShouldBeDeleted(endpoint *ec2.VpcEndpoint) bool {
+	state := aws.StringValue(endpoint.State)
+	if strings.EqualFold(state, ec2.StateDeleted) || strings.EqualFold(state, ec2.StateDeleting) {
+		return false
+	}
+
+	if endpoint.CreationTimestamp == nil {
+		// bad formed endpoint, should be deleted
+		return true
+	}
+
+	// do not delete recent endpoints.
+	if time.Now().UTC().Sub(*endpoint.CreationTimestamp) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func endpointServiceShouldBeDeleted(service *ec2.ServiceConfiguration) bool {
+	state := aws.StringValue(service.ServiceState)
+	if strings.EqualFold(state, ec2.ServiceStateDeleted) || strings.EqualFold(state, ec2.ServiceStateDeleting) {
+		return false
+	}
+
+	if !isCIEndpointService(service) {
+		return false
+	}
+
+	// do not delete services we saw first only recently.
+	return isFirstSeenBeforeGracePeriod(ec2Tags(service.Tags))
+}
+
+// isCIEndpointService returns true if the given endpoint service is tagged
+// for a CI cluster or is backed by a load balancer of a CI cluster.
+func isCIEndpointService(service *ec2.ServiceConfiguration) bool {
+	if isCITagged(ec2Tags(service.Tags)) {
+		return true
+	}
+
+	for _, arn := range service.NetworkLoadBalancerArns {
+		if isCIResource(loadBalancerNameFromARN(aws.StringValue(arn))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func vpcEndpointConnectionShouldBeRejected(c *ec2.VpcEndpointConnection) bool {
+	if !strings.EqualFold(aws.StringValue(c.VpcEndpointState), ec2.StatePendingAcceptance) {
+		return false
+	}
+
+	if c.CreationTimestamp == nil {
+		// bad formed connection, should be rejected
+		return true
+	}
+
+	// do not reject recent connection requests.
+	if time.Now().UTC().Sub(*c.CreationTimestamp) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func isVPCEndpointConnectionClosed(c *ec2.VpcEndpointConnection) bool {
+	closed := []string{
+		ec2.StateDeleted,
+		ec2.StateDeleting,
+		ec2.StateExpired,
+		ec2.StateFailed,
+		ec2.StateRejected,
+	}
+	for _, state := range closed {
+		if strings.EqualFold(aws.StringValue(c.VpcEndpointState), state) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadBalancerNameFromARN returns the name of the load balancer identified by
+// the given ARN, e.g. arn:aws:elasticloadbalancing:eu-central-1:123456789012:loadbalancer/net/name/0123456789abcdef.
+func loadBalancerNameFromARN(arn string) string {
+	parts := strings.Split(arn, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+
+	return parts[len(parts)-2]
+}
+
+func unsuccessfulItemMessage(item *ec2.UnsuccessfulItem) string {
+	if item.Error == nil {
+		return fmt.Sprintf("%s failed", aws.StringValue(item.ResourceId))
+	}
+
+	return fmt.Sprintf("%s: %s: %s", aws.StringValue(item.ResourceId), aws.StringValue(item.Error.Code), aws.StringValue(item.Error.Message))
+}
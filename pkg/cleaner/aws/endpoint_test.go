@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEndpointServiceShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		service     *ec2.ServiceConfiguration
+		expected    bool
+		description string
+	}{
+		{
+			description: "service of other load balancer should not be deleted",
+			service: &ec2.ServiceConfiguration{
+				NetworkLoadBalancerArns: []*string{
+					aws.String("arn:aws:elasticloadbalancing:eu-central-1:123456789012:loadbalancer/net/8y5ck-api/0123456789abcdef"),
+				},
+				ServiceId:    aws.String("vpce-svc-1"),
+				ServiceState: aws.String("Available"),
+			},
+			expected: false,
+		},
+		{
+			description: "service of ci load balancer not seen before should not be deleted",
+			service: &ec2.ServiceConfiguration{
+				NetworkLoadBalancerArns: []*string{
+					aws.String("arn:aws:elasticloadbalancing:eu-central-1:123456789012:loadbalancer/net/ci-wip-50a83-api/0123456789abcdef"),
+				},
+				ServiceId:    aws.String("vpce-svc-2"),
+				ServiceState: aws.String("Available"),
+			},
+			expected: false,
+		},
+		{
+			description: "service of ci load balancer seen long ago should be deleted",
+			service: &ec2.ServiceConfiguration{
+				NetworkLoadBalancerArns: []*string{
+					aws.String("arn:aws:elasticloadbalancing:eu-central-1:123456789012:loadbalancer/net/ci-wip-50a83-api/0123456789abcdef"),
+				},
+				ServiceId:    aws.String("vpce-svc-3"),
+				ServiceState: aws.String("Available"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("ci-cleaner.giantswarm.io/first-seen"),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "ci tagged service seen recently should not be deleted",
+			service: &ec2.ServiceConfiguration{
+				ServiceId:    aws.String("vpce-svc-4"),
+				ServiceState: aws.String("Available"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("ci-wip-50a83-d4f51"),
+					},
+					{
+						Key:   aws.String("ci-cleaner.giantswarm.io/first-seen"),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "deleting ci service should not be deleted",
+			service: &ec2.ServiceConfiguration{
+				ServiceId:    aws.String("vpce-svc-5"),
+				ServiceState: aws.String("Deleting"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("ci-wip-50a83-d4f51"),
+					},
+					{
+						Key:   aws.String("ci-cleaner.giantswarm.io/first-seen"),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := endpointServiceShouldBeDeleted(tc.service)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.service.ServiceId, tc.expected, actual)
+			}
+		})
+	}
+}
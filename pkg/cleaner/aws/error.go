@@ -1,6 +1,9 @@
 package aws
 
 import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/giantswarm/microerror"
 )
 
@@ -17,7 +20,102 @@ var notFoundError = &microerror.Error{
 	Kind: "notFoundError",
 }
 
-// IsNotFound asserts notFoundError.
+// IsNotFound asserts notFoundError and AWS errors telling that the requested
+// resource does not exist.
 func IsNotFound(err error) bool {
-	return microerror.Cause(err) == notFoundError
+	if err == nil {
+		return false
+	}
+
+	c := microerror.Cause(err)
+
+	if c == notFoundError {
+		return true
+	}
+
+	{
+		aErr, ok := c.(awserr.Error)
+		if ok && strings.HasSuffix(aErr.Code(), "NotFound") {
+			return true
+		}
+		if ok && aErr.Code() == "NoSuchEntity" {
+			return true
+		}
+		if ok && strings.HasSuffix(aErr.Code(), ".NonExistentQueue") {
+			return true
+		}
+		if ok && strings.HasSuffix(aErr.Code(), "NotFoundException") {
+			return true
+		}
+	}
+
+	return false
+}
+
+var dependencyViolationError = &microerror.Error{
+	Kind: "dependencyViolationError",
+}
+
+// IsDependencyViolation asserts dependencyViolationError and AWS errors
+// telling that the resource cannot be deleted because other resources depend
+// on it.
+func IsDependencyViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	c := microerror.Cause(err)
+
+	if c == dependencyViolationError {
+		return true
+	}
+
+	{
+		aErr, ok := c.(awserr.Error)
+		if ok && aErr.Code() == "DependencyViolation" {
+			return true
+		}
+	}
+
+	return false
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var timeoutError = &microerror.Error{
+	Kind: "timeoutError",
+}
+
+// IsTimeout asserts timeoutError.
+func IsTimeout(err error) bool {
+	return microerror.Cause(err) == timeoutError
+}
+
+// IsInUse asserts AWS errors telling that the resource is still in use and
+// cannot be deleted yet.
+func IsInUse(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	c := microerror.Cause(err)
+
+	{
+		aErr, ok := c.(awserr.Error)
+		if ok && strings.HasSuffix(aErr.Code(), ".InUse") {
+			return true
+		}
+		if ok && strings.HasPrefix(aErr.Code(), "ResourceInUse") {
+			return true
+		}
+	}
+
+	return false
 }
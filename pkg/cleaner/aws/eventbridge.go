@@ -0,0 +1,205 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanEventBridgeRules deletes the EventBridge rules of CI clusters on the
+// default event bus, e.g. the ones forwarding spot interruption and Auto
+// Scaling lifecycle events to the queue of aws-node-termination-handler. Rules
+// cannot be deleted while they have targets, so their targets are removed
+// first.
+func (a *Cleaner) cleanEventBridgeRules() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &eventbridge.ListRulesInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.eventBridgeClient.ListRules(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, rule := range o.Rules {
+			name := *rule.Name
+
+			// rules managed by other AWS services are deleted by them.
+			if rule.ManagedBy != nil {
+				continue
+			}
+
+			tags, err := a.eventBridgeRuleTags(rule.Arn)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of eventbridge rule %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			// rules do not have a creation time, so we remember the time we saw
+			// them first.
+			if isCIEventBridgeRule(rule, tags) {
+				if _, ok := tags[tagFirstSeen]; !ok {
+					err := a.tagEventBridgeRuleFirstSeen(rule.Arn)
+					if err != nil {
+						errors.Append(microerror.Mask(err))
+						a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging eventbridge rule %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+					}
+					continue
+				}
+			}
+
+			if !eventBridgeRuleShouldBeDeleted(rule, tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that eventbridge rule %#q should be deleted", name))
+
+			err = a.deleteEventBridgeRule(rule)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("eventbridge rule %#q does not exist anymore", name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting eventbridge rule %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted eventbridge rule %#q", name))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteEventBridgeRule(rule *eventbridge.Rule) error {
+	var nextToken *string
+	for {
+		i := &eventbridge.ListTargetsByRuleInput{
+			EventBusName: rule.EventBusName,
+			NextToken:    nextToken,
+			Rule:         rule.Name,
+		}
+
+		o, err := a.eventBridgeClient.ListTargetsByRule(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if len(o.Targets) > 0 {
+			var ids []*string
+			for _, target := range o.Targets {
+				ids = append(ids, target.Id)
+			}
+
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("removing %d targets of eventbridge rule %#q", len(ids), *rule.Name))
+
+			i := &eventbridge.RemoveTargetsInput{
+				EventBusName: rule.EventBusName,
+				Ids:          ids,
+				Rule:         rule.Name,
+			}
+			o, err := a.eventBridgeClient.RemoveTargets(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			if len(o.FailedEntries) > 0 {
+				entry := o.FailedEntries[0]
+				return microerror.Maskf(executionFailedError, "failed removing %d targets, e.g. %#q: %s", len(o.FailedEntries), aws.StringValue(entry.TargetId), aws.StringValue(entry.ErrorMessage))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	{
+		i := &eventbridge.DeleteRuleInput{
+			EventBusName: rule.EventBusName,
+			Name:         rule.Name,
+		}
+		_, err := a.eventBridgeClient.DeleteRule(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) eventBridgeRuleTags(arn *string) (map[string]string, error) {
+	i := &eventbridge.ListTagsForResourceInput{
+		ResourceARN: arn,
+	}
+	o, err := a.eventBridgeClient.ListTagsForResource(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tags := map[string]string{}
+	for _, t := range o.Tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		tags[*t.Key] = *t.Value
+	}
+
+	return tags, nil
+}
+
+// tagEventBridgeRuleFirstSeen tags the given EventBridge rule with the current
+// time, so that we can tell its age in later runs.
+func (a *Cleaner) tagEventBridgeRuleFirstSeen(arn *string) error {
+	i := &eventbridge.TagResourceInput{
+		ResourceARN: arn,
+		Tags: []*eventbridge.Tag{
+			{
+				Key:   aws.String(tagFirstSeen),
+				Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+			},
+		},
+	}
+	_, err := a.eventBridgeClient.TagResource(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func isCIEventBridgeRule(rule *eventbridge.Rule, tags map[string]string) bool {
+	return isCIResource(aws.StringValue(rule.Name)) || isCITagged(tags)
+}
+
+func eventBridgeRuleShouldBeDeleted(rule *eventbridge.Rule, tags map[string]string) bool {
+	if rule.ManagedBy != nil {
+		return false
+	}
+
+	if !isCIEventBridgeRule(rule, tags) {
+		return false
+	}
+
+	return isFirstSeenBeforeGracePeriod(tags)
+}
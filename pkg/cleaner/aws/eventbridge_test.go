@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+)
+
+func TestEventBridgeRuleShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		rule        *eventbridge.Rule
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "ci rule first seen long ago should be deleted",
+			rule: &eventbridge.Rule{
+				Name: aws.String("ci-wip-1a2b3-spot-interruption"),
+			},
+			tags: map[string]string{
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: true,
+		},
+		{
+			description: "rule tagged for ci cluster first seen long ago should be deleted",
+			rule: &eventbridge.Rule{
+				Name: aws.String("nth-asg-termination"),
+			},
+			tags: map[string]string{
+				tagCluster:   "ci-wip-1a2b3",
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: true,
+		},
+		{
+			description: "ci rule first seen recently should not be deleted",
+			rule: &eventbridge.Rule{
+				Name: aws.String("ci-wip-1a2b3-spot-interruption"),
+			},
+			tags: map[string]string{
+				tagFirstSeen: time.Now().UTC().Format(time.RFC3339),
+			},
+			expected: false,
+		},
+		{
+			description: "ci rule not seen before should not be deleted",
+			rule: &eventbridge.Rule{
+				Name: aws.String("ci-wip-1a2b3-spot-interruption"),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+		{
+			description: "managed ci rule should not be deleted",
+			rule: &eventbridge.Rule{
+				ManagedBy: aws.String("autoscaling.amazonaws.com"),
+				Name:      aws.String("ci-wip-1a2b3-spot-interruption"),
+			},
+			tags: map[string]string{
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: false,
+		},
+		{
+			description: "other rule first seen long ago should not be deleted",
+			rule: &eventbridge.Rule{
+				Name: aws.String("nth-asg-termination"),
+			},
+			tags: map[string]string{
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := eventBridgeRuleShouldBeDeleted(tc.rule, tc.tags)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.rule.Name, tc.expected, actual)
+			}
+		})
+	}
+}
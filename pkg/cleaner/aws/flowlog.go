@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanFlowLogs deletes the flow logs of CI clusters, e.g. created by network
+// tests for VPCs, subnets or network interfaces. Flow logs of CI VPCs are
+// deleted as well, in case they are not tagged themselves.
+func (a *Cleaner) cleanFlowLogs() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeFlowLogsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeFlowLogs(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		var ids []*string
+		for _, flowLog := range o.FlowLogs {
+			if !flowLogShouldBeDeleted(flowLog, vpcs) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that flow log %#q of %#q should be deleted", *flowLog.FlowLogId, aws.StringValue(flowLog.ResourceId)))
+
+			ids = append(ids, flowLog.FlowLogId)
+		}
+
+		if len(ids) > 0 {
+			err := a.deleteFlowLogs(ids)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteFlowLogs(ids []*string) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	i := &ec2.DeleteFlowLogsInput{
+		FlowLogIds: ids,
+	}
+	o, err := a.ec2Client.DeleteFlowLogs(i)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting flow logs %s: %#v", strings.Join(aws.StringValueSlice(ids), ", "), err), "stack", fmt.Sprintf("%#v", err))
+		return errors
+	}
+
+	failed := map[string]bool{}
+	for _, item := range o.Unsuccessful {
+		id := aws.StringValue(item.ResourceId)
+		failed[id] = true
+
+		var code, message string
+		if item.Error != nil {
+			code = aws.StringValue(item.Error.Code)
+			message = aws.StringValue(item.Error.Message)
+		}
+
+		if strings.HasSuffix(code, ".NotFound") {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("flow log %#q does not exist anymore", id))
+			continue
+		}
+
+		err := microerror.Maskf(executionFailedError, "failed deleting flow log %#q: %s: %s", id, code, message)
+		errors.Append(err)
+		// do not return on error, try to continue deleting.
+		a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting flow log %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	for _, id := range ids {
+		if !failed[*id] {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted flow log %#q", *id))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func flowLogShouldBeDeleted(flowLog *ec2.FlowLog, vpcs map[string]bool) bool {
+	if !vpcs[aws.StringValue(flowLog.ResourceId)] && !isCITagged(ec2Tags(flowLog.Tags)) {
+		return false
+	}
+
+	if flowLog.CreationTime == nil {
+		// bad formed flow log, should be deleted
+		return true
+	}
+
+	// do not delete recent flow logs.
+	if time.Now().UTC().Sub(*flowLog.CreationTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
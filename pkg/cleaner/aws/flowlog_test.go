@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestFlowLogShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		flowLog     *ec2.FlowLog
+		expected    bool
+		description string
+	}{
+		{
+			description: "old flow log of ci vpc should be deleted",
+			flowLog: &ec2.FlowLog{
+				CreationTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				FlowLogId:    aws.String("fl-1a2b3c4d"),
+				ResourceId:   aws.String("vpc-1a2b3c4d"),
+			},
+			expected: true,
+		},
+		{
+			description: "old flow log tagged for ci cluster should be deleted",
+			flowLog: &ec2.FlowLog{
+				CreationTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				FlowLogId:    aws.String("fl-1a2b3c4d"),
+				ResourceId:   aws.String("subnet-1a2b3c4d"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent flow log of ci vpc should not be deleted",
+			flowLog: &ec2.FlowLog{
+				CreationTime: aws.Time(time.Now()),
+				FlowLogId:    aws.String("fl-1a2b3c4d"),
+				ResourceId:   aws.String("vpc-1a2b3c4d"),
+			},
+			expected: false,
+		},
+		{
+			description: "old flow log of non ci vpc should not be deleted",
+			flowLog: &ec2.FlowLog{
+				CreationTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				FlowLogId:    aws.String("fl-5e6f7a8b"),
+				ResourceId:   aws.String("vpc-5e6f7a8b"),
+			},
+			expected: false,
+		},
+	}
+
+	vpcs := map[string]bool{
+		"vpc-1a2b3c4d": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := flowLogShouldBeDeleted(tc.flowLog, vpcs)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.flowLog.FlowLogId, tc.expected, result)
+			}
+		})
+	}
+}
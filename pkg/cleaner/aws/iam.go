@@ -0,0 +1,625 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// iamServiceRolePathPrefix is the path prefix of service-linked roles.
+	// They are managed by the AWS services themselves and cannot be deleted
+	// directly.
+	iamServiceRolePathPrefix = "/aws-service-role/"
+)
+
+// cleanIAMInstanceProfiles deletes the instance profiles of CI clusters. Their
+// roles have to be removed from them first. IAM names are global per account,
+// so leftover instance profiles eventually collide with new CI runs.
+func (a *Cleaner) cleanIAMInstanceProfiles() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var marker *string
+	for {
+		i := &iam.ListInstanceProfilesInput{
+			Marker: marker,
+		}
+
+		o, err := a.iamClient.ListInstanceProfiles(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, profile := range o.InstanceProfiles {
+			// instance profiles are listed without their tags, so we only
+			// fetch them for instance profiles which could be deleted and are
+			// not recognized by name.
+			if !isCIResource(aws.StringValue(profile.InstanceProfileName)) && isIAMInstanceProfileDeletable(profile) {
+				tags, err := a.iamInstanceProfileTags(*profile.InstanceProfileName)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of instance profile %#q: %#v", *profile.InstanceProfileName, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+				profile.Tags = tags
+			}
+
+			if !iamInstanceProfileShouldBeDeleted(profile) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that instance profile %#q should be deleted", *profile.InstanceProfileName))
+
+			err := a.deleteIAMInstanceProfile(profile)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("instance profile %#q does not exist anymore", *profile.InstanceProfileName))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting instance profile %#q: %#v", *profile.InstanceProfileName, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted instance profile %#q", *profile.InstanceProfileName))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		marker = o.Marker
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) iamInstanceProfileTags(name string) ([]*iam.Tag, error) {
+	var tags []*iam.Tag
+
+	var marker *string
+	for {
+		i := &iam.ListInstanceProfileTagsInput{
+			InstanceProfileName: aws.String(name),
+			Marker:              marker,
+		}
+
+		o, err := a.iamClient.ListInstanceProfileTags(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		tags = append(tags, o.Tags...)
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		marker = o.Marker
+	}
+
+	return tags, nil
+}
+
+func (a *Cleaner) deleteIAMInstanceProfile(profile *iam.InstanceProfile) error {
+	for _, role := range profile.Roles {
+		i := &iam.RemoveRoleFromInstanceProfileInput{
+			InstanceProfileName: profile.InstanceProfileName,
+			RoleName:            role.RoleName,
+		}
+		_, err := a.iamClient.RemoveRoleFromInstanceProfile(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &iam.DeleteInstanceProfileInput{
+			InstanceProfileName: profile.InstanceProfileName,
+		}
+		_, err := a.iamClient.DeleteInstanceProfile(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// cleanIAMRoles deletes the roles of CI clusters. Roles can only be deleted
+// once they are removed from all instance profiles, their managed policies are
+// detached and their inline policies are deleted.
+func (a *Cleaner) cleanIAMRoles() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var marker *string
+	for {
+		i := &iam.ListRolesInput{
+			Marker: marker,
+		}
+
+		o, err := a.iamClient.ListRoles(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, role := range o.Roles {
+			// roles are listed without their tags, so we only fetch them for
+			// roles which could be deleted and are not recognized by name.
+			if !isCIResource(aws.StringValue(role.RoleName)) && isIAMRoleDeletable(role) {
+				tags, err := a.iamRoleTags(*role.RoleName)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of role %#q: %#v", *role.RoleName, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+				role.Tags = tags
+			}
+
+			if !iamRoleShouldBeDeleted(role) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that role %#q should be deleted", *role.RoleName))
+
+			err := a.deleteIAMRole(role)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("role %#q does not exist anymore", *role.RoleName))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting role %#q: %#v", *role.RoleName, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted role %#q", *role.RoleName))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		marker = o.Marker
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) iamRoleTags(name string) ([]*iam.Tag, error) {
+	var tags []*iam.Tag
+
+	var marker *string
+	for {
+		i := &iam.ListRoleTagsInput{
+			Marker:   marker,
+			RoleName: aws.String(name),
+		}
+
+		o, err := a.iamClient.ListRoleTags(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		tags = append(tags, o.Tags...)
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		marker = o.Marker
+	}
+
+	return tags, nil
+}
+
+func (a *Cleaner) deleteIAMRole(role *iam.Role) error {
+	{
+		var marker *string
+		for {
+			i := &iam.ListInstanceProfilesForRoleInput{
+				Marker:   marker,
+				RoleName: role.RoleName,
+			}
+			o, err := a.iamClient.ListInstanceProfilesForRole(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, profile := range o.InstanceProfiles {
+				i := &iam.RemoveRoleFromInstanceProfileInput{
+					InstanceProfileName: profile.InstanceProfileName,
+					RoleName:            role.RoleName,
+				}
+				_, err := a.iamClient.RemoveRoleFromInstanceProfile(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				break
+			}
+			marker = o.Marker
+		}
+	}
+
+	{
+		var marker *string
+		for {
+			i := &iam.ListAttachedRolePoliciesInput{
+				Marker:   marker,
+				RoleName: role.RoleName,
+			}
+			o, err := a.iamClient.ListAttachedRolePolicies(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, policy := range o.AttachedPolicies {
+				i := &iam.DetachRolePolicyInput{
+					PolicyArn: policy.PolicyArn,
+					RoleName:  role.RoleName,
+				}
+				_, err := a.iamClient.DetachRolePolicy(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				break
+			}
+			marker = o.Marker
+		}
+	}
+
+	{
+		var marker *string
+		for {
+			i := &iam.ListRolePoliciesInput{
+				Marker:   marker,
+				RoleName: role.RoleName,
+			}
+			o, err := a.iamClient.ListRolePolicies(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, name := range o.PolicyNames {
+				i := &iam.DeleteRolePolicyInput{
+					PolicyName: name,
+					RoleName:   role.RoleName,
+				}
+				_, err := a.iamClient.DeleteRolePolicy(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				break
+			}
+			marker = o.Marker
+		}
+	}
+
+	{
+		i := &iam.DeleteRoleInput{
+			RoleName: role.RoleName,
+		}
+		_, err := a.iamClient.DeleteRole(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// cleanIAMPolicies deletes the customer managed policies of CI clusters.
+// Policies can only be deleted once they are detached from all roles, users
+// and groups and all their non-default versions are deleted.
+func (a *Cleaner) cleanIAMPolicies() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var marker *string
+	for {
+		i := &iam.ListPoliciesInput{
+			Marker: marker,
+			Scope:  aws.String(iam.PolicyScopeTypeLocal),
+		}
+
+		o, err := a.iamClient.ListPolicies(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, policy := range o.Policies {
+			// policies are listed without their tags, so we only fetch them
+			// for policies which could be deleted and are not recognized by
+			// name.
+			if !isCIResource(aws.StringValue(policy.PolicyName)) && isIAMPolicyDeletable(policy) {
+				tags, err := a.iamPolicyTags(policy.Arn)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of policy %#q: %#v", *policy.PolicyName, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+				policy.Tags = tags
+			}
+
+			if !iamPolicyShouldBeDeleted(policy) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that policy %#q should be deleted", *policy.PolicyName))
+
+			err := a.deleteIAMPolicy(policy)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("policy %#q does not exist anymore", *policy.PolicyName))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting policy %#q: %#v", *policy.PolicyName, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted policy %#q", *policy.PolicyName))
+			}
+		}
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		marker = o.Marker
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) iamPolicyTags(arn *string) ([]*iam.Tag, error) {
+	var tags []*iam.Tag
+
+	var marker *string
+	for {
+		i := &iam.ListPolicyTagsInput{
+			Marker:    marker,
+			PolicyArn: arn,
+		}
+
+		o, err := a.iamClient.ListPolicyTags(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		tags = append(tags, o.Tags...)
+
+		if !aws.BoolValue(o.IsTruncated) {
+			break
+		}
+		marker = o.Marker
+	}
+
+	return tags, nil
+}
+
+func (a *Cleaner) deleteIAMPolicy(policy *iam.Policy) error {
+	{
+		var marker *string
+		for {
+			i := &iam.ListEntitiesForPolicyInput{
+				Marker:    marker,
+				PolicyArn: policy.Arn,
+			}
+			o, err := a.iamClient.ListEntitiesForPolicy(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, role := range o.PolicyRoles {
+				i := &iam.DetachRolePolicyInput{
+					PolicyArn: policy.Arn,
+					RoleName:  role.RoleName,
+				}
+				_, err := a.iamClient.DetachRolePolicy(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			for _, user := range o.PolicyUsers {
+				i := &iam.DetachUserPolicyInput{
+					PolicyArn: policy.Arn,
+					UserName:  user.UserName,
+				}
+				_, err := a.iamClient.DetachUserPolicy(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			for _, group := range o.PolicyGroups {
+				i := &iam.DetachGroupPolicyInput{
+					GroupName: group.GroupName,
+					PolicyArn: policy.Arn,
+				}
+				_, err := a.iamClient.DetachGroupPolicy(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				break
+			}
+			marker = o.Marker
+		}
+	}
+
+	{
+		var marker *string
+		for {
+			i := &iam.ListPolicyVersionsInput{
+				Marker:    marker,
+				PolicyArn: policy.Arn,
+			}
+			o, err := a.iamClient.ListPolicyVersions(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, version := range o.Versions {
+				// the default version is deleted together with the policy.
+				if aws.BoolValue(version.IsDefaultVersion) {
+					continue
+				}
+
+				i := &iam.DeletePolicyVersionInput{
+					PolicyArn: policy.Arn,
+					VersionId: version.VersionId,
+				}
+				_, err := a.iamClient.DeletePolicyVersion(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if !aws.BoolValue(o.IsTruncated) {
+				break
+			}
+			marker = o.Marker
+		}
+	}
+
+	{
+		i := &iam.DeletePolicyInput{
+			PolicyArn: policy.Arn,
+		}
+		_, err := a.iamClient.DeletePolicy(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// isIAMInstanceProfileDeletable returns true if the given instance profile may
+// be deleted regardless of whether it belongs to a CI cluster, i.e. it is older
+// than gracePeriod.
+func isIAMInstanceProfileDeletable(profile *iam.InstanceProfile) bool {
+	if profile.CreateDate == nil {
+		// bad formed instance profile, should be deleted
+		return true
+	}
+
+	// do not delete recent instance profiles.
+	if time.Now().UTC().Sub(*profile.CreateDate) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func iamInstanceProfileShouldBeDeleted(profile *iam.InstanceProfile) bool {
+	if !isCIResource(aws.StringValue(profile.InstanceProfileName)) && !isCITagged(iamTags(profile.Tags)) {
+		return false
+	}
+
+	return isIAMInstanceProfileDeletable(profile)
+}
+
+// isIAMRoleDeletable returns true if the given role may be deleted regardless
+// of whether it belongs to a CI cluster, i.e. it is no service-linked role and
+// is older than gracePeriod.
+func isIAMRoleDeletable(role *iam.Role) bool {
+	if strings.HasPrefix(aws.StringValue(role.Path), iamServiceRolePathPrefix) {
+		return false
+	}
+
+	if role.CreateDate == nil {
+		// bad formed role, should be deleted
+		return true
+	}
+
+	// do not delete recent roles.
+	if time.Now().UTC().Sub(*role.CreateDate) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func iamRoleShouldBeDeleted(role *iam.Role) bool {
+	if !isCIResource(aws.StringValue(role.RoleName)) && !isCITagged(iamTags(role.Tags)) {
+		return false
+	}
+
+	return isIAMRoleDeletable(role)
+}
+
+// isIAMPolicyDeletable returns true if the given policy may be deleted
+// regardless of whether it belongs to a CI cluster, i.e. it is older than
+// gracePeriod.
+func isIAMPolicyDeletable(policy *iam.Policy) bool {
+	if policy.CreateDate == nil {
+		// bad formed policy, should be deleted
+		return true
+	}
+
+	// do not delete recent policies.
+	if time.Now().UTC().Sub(*policy.CreateDate) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func iamPolicyShouldBeDeleted(policy *iam.Policy) bool {
+	if !isCIResource(aws.StringValue(policy.PolicyName)) && !isCITagged(iamTags(policy.Tags)) {
+		return false
+	}
+
+	return isIAMPolicyDeletable(policy)
+}
+
+// iamTags converts the given IAM tags into a map of tag keys and values.
+func iamTags(tags []*iam.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		m[*t.Key] = *t.Value
+	}
+
+	return m
+}
@@ -0,0 +1,193 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestIAMInstanceProfileShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		profile     *iam.InstanceProfile
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci instance profile should be deleted",
+			profile: &iam.InstanceProfile{
+				CreateDate:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				InstanceProfileName: aws.String("ci-wip-1a2b3-worker-EC2-K8S-Role"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci instance profile should not be deleted",
+			profile: &iam.InstanceProfile{
+				CreateDate:          aws.Time(time.Now()),
+				InstanceProfileName: aws.String("ci-wip-1a2b3-worker-EC2-K8S-Role"),
+			},
+			expected: false,
+		},
+		{
+			description: "old instance profile tagged for ci cluster should be deleted",
+			profile: &iam.InstanceProfile{
+				CreateDate:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				InstanceProfileName: aws.String("nodes-profile"),
+				Tags: []*iam.Tag{
+					{
+						Key:   aws.String(tagClusterAPIClusterPrefix + "ci-capa-1a2b3"),
+						Value: aws.String("owned"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old other instance profile should not be deleted",
+			profile: &iam.InstanceProfile{
+				CreateDate:          aws.Time(time.Now().Add(-2 * time.Hour)),
+				InstanceProfileName: aws.String("abc12-worker-EC2-K8S-Role"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := iamInstanceProfileShouldBeDeleted(tc.profile)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.profile.InstanceProfileName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIAMRoleShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		role        *iam.Role
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci role should be deleted",
+			role: &iam.Role{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Path:       aws.String("/"),
+				RoleName:   aws.String("ci-wip-1a2b3-master-EC2-K8S-Role"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci role should not be deleted",
+			role: &iam.Role{
+				CreateDate: aws.Time(time.Now()),
+				Path:       aws.String("/"),
+				RoleName:   aws.String("ci-wip-1a2b3-master-EC2-K8S-Role"),
+			},
+			expected: false,
+		},
+		{
+			description: "old role tagged for ci cluster should be deleted",
+			role: &iam.Role{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Path:       aws.String("/"),
+				RoleName:   aws.String("nodes.cluster-api-provider-aws.sigs.k8s.io"),
+				Tags: []*iam.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old service-linked role should not be deleted",
+			role: &iam.Role{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Path:       aws.String("/aws-service-role/autoscaling.amazonaws.com/"),
+				RoleName:   aws.String("ci-wip-1a2b3-service-role"),
+			},
+			expected: false,
+		},
+		{
+			description: "old other role should not be deleted",
+			role: &iam.Role{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Path:       aws.String("/"),
+				RoleName:   aws.String("abc12-master-EC2-K8S-Role"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := iamRoleShouldBeDeleted(tc.role)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.role.RoleName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIAMPolicyShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		policy      *iam.Policy
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci policy should be deleted",
+			policy: &iam.Policy{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				PolicyName: aws.String("ci-wip-1a2b3-worker-EC2-K8S-Policy"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci policy should not be deleted",
+			policy: &iam.Policy{
+				CreateDate: aws.Time(time.Now()),
+				PolicyName: aws.String("ci-wip-1a2b3-worker-EC2-K8S-Policy"),
+			},
+			expected: false,
+		},
+		{
+			description: "old policy tagged for ci cluster should be deleted",
+			policy: &iam.Policy{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				PolicyName: aws.String("nodes-policy"),
+				Tags: []*iam.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old other policy should not be deleted",
+			policy: &iam.Policy{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				PolicyName: aws.String("abc12-worker-EC2-K8S-Policy"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := iamPolicyShouldBeDeleted(tc.policy)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.policy.PolicyName, tc.expected, actual)
+			}
+		})
+	}
+}
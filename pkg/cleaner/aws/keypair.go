@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanKeyPairs deletes the SSH key pairs imported by CI test runs. Key pairs
+// still referenced by an existing Auto Scaling group are kept, so that the
+// group is still able to launch instances until it is deleted itself.
+func (a *Cleaner) cleanKeyPairs() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	refs, err := a.autoScalingReferences()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	o, err := a.ec2Client.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, kp := range o.KeyPairs {
+		// key pairs imported before AWS tracked their creation time do not
+		// have one, so we remember the time we saw them first.
+		if isCIKeyPair(kp) && kp.CreateTime == nil && kp.KeyPairId != nil {
+			if _, ok := ec2Tags(kp.Tags)[tagFirstSeen]; !ok {
+				err := a.tagFirstSeen(*kp.KeyPairId)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging key pair %#q: %#v", *kp.KeyName, err), "stack", fmt.Sprintf("%#v", err))
+				}
+				continue
+			}
+		}
+
+		if !keyPairShouldBeDeleted(kp, refs.keyPairs) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that key pair %#q should be deleted", *kp.KeyName))
+
+		i := &ec2.DeleteKeyPairInput{
+			KeyName: kp.KeyName,
+		}
+		_, err := a.ec2Client.DeleteKeyPair(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting key pair %#q: %#v", *kp.KeyName, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted key pair %#q", *kp.KeyName))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func isCIKeyPair(kp *ec2.KeyPairInfo) bool {
+	return isCIResource(aws.StringValue(kp.KeyName)) || isCITagged(ec2Tags(kp.Tags))
+}
+
+func keyPairShouldBeDeleted(kp *ec2.KeyPairInfo, referenced map[string]bool) bool {
+	if kp.KeyName == nil {
+		return false
+	}
+
+	if !isCIKeyPair(kp) {
+		return false
+	}
+
+	// do not delete key pairs which are still used to launch instances.
+	if referenced[*kp.KeyName] {
+		return false
+	}
+
+	if kp.CreateTime == nil {
+		return isFirstSeenBeforeGracePeriod(ec2Tags(kp.Tags))
+	}
+
+	// do not delete recent key pairs.
+	if time.Now().UTC().Sub(*kp.CreateTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
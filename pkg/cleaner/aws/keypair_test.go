@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestKeyPairShouldBeDeleted(t *testing.T) {
+	referenced := map[string]bool{
+		"ci-used": true,
+	}
+
+	tcs := []struct {
+		kp          *ec2.KeyPairInfo
+		expected    bool
+		description string
+	}{
+		{
+			description: "ci key pair without first seen tag should not be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName: aws.String("ci-wip-1a2b3"),
+			},
+			expected: false,
+		},
+		{
+			description: "ci key pair first seen recently should not be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName: aws.String("ci-wip-1a2b3"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "ci key pair first seen long ago should be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName: aws.String("ci-wip-1a2b3"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "key pair tagged for ci cluster first seen long ago should be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName: aws.String("vault"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci key pair should not be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName:    aws.String("ci-wip-1a2b3"),
+				CreateTime: aws.Time(time.Now()),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci key pair should be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName:    aws.String("ci-wip-1a2b3"),
+				CreateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+			},
+			expected: true,
+		},
+		{
+			description: "old ci key pair first seen recently should be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName:    aws.String("ci-wip-1a2b3"),
+				CreateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "ci key pair used by a group should not be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName:    aws.String("ci-used"),
+				CreateTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "other key pair should not be deleted",
+			kp: &ec2.KeyPairInfo{
+				KeyName: aws.String("vault"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := keyPairShouldBeDeleted(tc.kp, referenced)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.kp.KeyName, tc.expected, actual)
+			}
+		})
+	}
+}
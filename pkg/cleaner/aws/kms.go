@@ -0,0 +1,269 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// kmsAliasPrefix is the prefix of all KMS alias names.
+	kmsAliasPrefix = "alias/"
+
+	// minKMSPendingWindowDays and maxKMSPendingWindowDays are the bounds of
+	// the waiting period KMS allows before a key scheduled for deletion is
+	// deleted.
+	minKMSPendingWindowDays = 7
+	maxKMSPendingWindowDays = 30
+)
+
+// cleanKMSKeys schedules the deletion of customer managed KMS keys of CI
+// clusters. KMS keys cannot be deleted right away, so their aliases are
+// deleted and the keys are disabled, so that they cannot be used anymore
+// during the pending window. Keys already pending deletion are only reported.
+func (a *Cleaner) cleanKMSKeys() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	aliases, err := a.kmsAliases()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var pending int
+	var marker *string
+	for {
+		i := &kms.ListKeysInput{
+			Marker: marker,
+		}
+
+		o, err := a.kmsClient.ListKeys(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, entry := range o.Keys {
+			id := *entry.KeyId
+
+			var key *kms.KeyMetadata
+			{
+				i := &kms.DescribeKeyInput{
+					KeyId: entry.KeyId,
+				}
+				o, err := a.kmsClient.DescribeKey(i)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed describing kms key %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+
+				key = o.KeyMetadata
+			}
+
+			if aws.StringValue(key.KeyManager) != kms.KeyManagerTypeCustomer {
+				continue
+			}
+
+			// keys are listed without their tags, so we only fetch them for
+			// keys which are not recognized by alias.
+			var tags map[string]string
+			if !isCIKMSAliased(aliases[id]) {
+				tags, err = a.kmsKeyTags(id)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of kms key %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+			}
+
+			if aws.StringValue(key.KeyState) == kms.KeyStatePendingDeletion && (isCIKMSAliased(aliases[id]) || isCITagged(tags)) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("kms key %#q is already pending deletion until %s", id, aws.TimeValue(key.DeletionDate).Format(time.RFC3339)))
+				pending++
+				continue
+			}
+
+			if !kmsKeyShouldBeDeleted(key, aliases[id], tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that kms key %#q should be deleted", id))
+
+			err := a.scheduleKMSKeyDeletion(key, aliases[id])
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("kms key %#q does not exist anymore", id))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed scheduling deletion of kms key %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("scheduled deletion of kms key %#q in %d days", id, a.kmsPendingWindowDays))
+			}
+		}
+
+		if !aws.BoolValue(o.Truncated) {
+			break
+		}
+		marker = o.NextMarker
+	}
+
+	if pending > 0 {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found %d kms keys of ci clusters already pending deletion", pending))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// kmsAliases returns the names of all KMS aliases mapped by the ID of the key
+// they point to.
+func (a *Cleaner) kmsAliases() (map[string][]string, error) {
+	aliases := map[string][]string{}
+
+	var marker *string
+	for {
+		i := &kms.ListAliasesInput{
+			Marker: marker,
+		}
+
+		o, err := a.kmsClient.ListAliases(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, alias := range o.Aliases {
+			// aliases of AWS managed keys and unused aliases do not point to
+			// any key.
+			if alias.TargetKeyId == nil {
+				continue
+			}
+			aliases[*alias.TargetKeyId] = append(aliases[*alias.TargetKeyId], aws.StringValue(alias.AliasName))
+		}
+
+		if !aws.BoolValue(o.Truncated) {
+			break
+		}
+		marker = o.NextMarker
+	}
+
+	return aliases, nil
+}
+
+func (a *Cleaner) kmsKeyTags(id string) (map[string]string, error) {
+	tags := map[string]string{}
+
+	var marker *string
+	for {
+		i := &kms.ListResourceTagsInput{
+			KeyId:  aws.String(id),
+			Marker: marker,
+		}
+
+		o, err := a.kmsClient.ListResourceTags(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, t := range o.Tags {
+			if t.TagKey == nil || t.TagValue == nil {
+				continue
+			}
+			tags[*t.TagKey] = *t.TagValue
+		}
+
+		if !aws.BoolValue(o.Truncated) {
+			break
+		}
+		marker = o.NextMarker
+	}
+
+	return tags, nil
+}
+
+func (a *Cleaner) scheduleKMSKeyDeletion(key *kms.KeyMetadata, aliases []string) error {
+	for _, alias := range aliases {
+		i := &kms.DeleteAliasInput{
+			AliasName: aws.String(alias),
+		}
+		_, err := a.kmsClient.DeleteAlias(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if aws.StringValue(key.KeyState) == kms.KeyStateEnabled {
+		i := &kms.DisableKeyInput{
+			KeyId: key.KeyId,
+		}
+		_, err := a.kmsClient.DisableKey(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &kms.ScheduleKeyDeletionInput{
+			KeyId:               key.KeyId,
+			PendingWindowInDays: aws.Int64(a.kmsPendingWindowDays),
+		}
+		_, err := a.kmsClient.ScheduleKeyDeletion(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// isCIKMSAliased returns true if any of the given alias names belongs to a CI
+// cluster.
+func isCIKMSAliased(aliases []string) bool {
+	for _, alias := range aliases {
+		if isCIResource(strings.TrimPrefix(alias, kmsAliasPrefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func kmsKeyShouldBeDeleted(key *kms.KeyMetadata, aliases []string, tags map[string]string) bool {
+	if aws.StringValue(key.KeyManager) != kms.KeyManagerTypeCustomer {
+		return false
+	}
+
+	if !isCIKMSAliased(aliases) && !isCITagged(tags) {
+		return false
+	}
+
+	// only keys which are not in a transitional or final state can be
+	// scheduled for deletion.
+	switch aws.StringValue(key.KeyState) {
+	case kms.KeyStateEnabled, kms.KeyStateDisabled, kms.KeyStatePendingImport:
+	default:
+		return false
+	}
+
+	if key.CreationDate == nil {
+		// bad formed key, should be deleted
+		return true
+	}
+
+	// do not delete recent keys.
+	if time.Now().UTC().Sub(*key.CreationDate) < gracePeriod {
+		return false
+	}
+
+	return true
+}
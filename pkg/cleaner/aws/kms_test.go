@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+func TestKMSKeyShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		key         *kms.KeyMetadata
+		aliases     []string
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old enabled key with ci alias should be deleted",
+			key: &kms.KeyMetadata{
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				KeyId:        aws.String("key-1"),
+				KeyManager:   aws.String(kms.KeyManagerTypeCustomer),
+				KeyState:     aws.String(kms.KeyStateEnabled),
+			},
+			aliases: []string{
+				"alias/ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recent enabled key with ci alias should not be deleted",
+			key: &kms.KeyMetadata{
+				CreationDate: aws.Time(time.Now()),
+				KeyId:        aws.String("key-2"),
+				KeyManager:   aws.String(kms.KeyManagerTypeCustomer),
+				KeyState:     aws.String(kms.KeyStateEnabled),
+			},
+			aliases: []string{
+				"alias/ci-wip-1a2b3",
+			},
+			expected: false,
+		},
+		{
+			description: "old disabled key tagged for ci cluster should be deleted",
+			key: &kms.KeyMetadata{
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				KeyId:        aws.String("key-3"),
+				KeyManager:   aws.String(kms.KeyManagerTypeCustomer),
+				KeyState:     aws.String(kms.KeyStateDisabled),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "old key pending deletion should not be deleted",
+			key: &kms.KeyMetadata{
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				KeyId:        aws.String("key-4"),
+				KeyManager:   aws.String(kms.KeyManagerTypeCustomer),
+				KeyState:     aws.String(kms.KeyStatePendingDeletion),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: false,
+		},
+		{
+			description: "old aws managed key should not be deleted",
+			key: &kms.KeyMetadata{
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				KeyId:        aws.String("key-5"),
+				KeyManager:   aws.String(kms.KeyManagerTypeAws),
+				KeyState:     aws.String(kms.KeyStateEnabled),
+			},
+			aliases: []string{
+				"alias/ci-wip-1a2b3",
+			},
+			expected: false,
+		},
+		{
+			description: "old other key should not be deleted",
+			key: &kms.KeyMetadata{
+				CreationDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				KeyId:        aws.String("key-6"),
+				KeyManager:   aws.String(kms.KeyManagerTypeCustomer),
+				KeyState:     aws.String(kms.KeyStateEnabled),
+			},
+			aliases: []string{
+				"alias/abc12",
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := kmsKeyShouldBeDeleted(tc.key, tc.aliases, tc.tags)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.key.KeyId, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// lambdaTimeFormat is the format Lambda uses for timestamps, e.g.
+	// 2020-01-02T15:04:05.000+0000.
+	lambdaTimeFormat = "2006-01-02T15:04:05.999-0700"
+)
+
+// cleanLambdaFunctions deletes the Lambda functions of CI clusters, e.g. the
+// ones handling DNS or lifecycle hooks in e2e tests. Their event source
+// mappings and function URL configs are deleted first. Permissions of other
+// services to invoke the functions are part of the function policy and go
+// away together with the functions. Lambda functions do not have a creation
+// time, so their last modification time is used instead.
+func (a *Cleaner) cleanLambdaFunctions() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var marker *string
+	for {
+		i := &lambda.ListFunctionsInput{
+			Marker: marker,
+		}
+
+		o, err := a.lambdaClient.ListFunctions(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, function := range o.Functions {
+			name := *function.FunctionName
+
+			// functions are listed without their tags, so we only fetch them
+			// for functions which could be deleted and are not recognized by
+			// name.
+			var tags map[string]string
+			if !isCIResource(name) && !isLambdaFunctionRecent(function) {
+				tags, err = a.lambdaFunctionTags(function.FunctionArn)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of lambda function %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+			}
+
+			if !lambdaFunctionShouldBeDeleted(function, tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that lambda function %#q should be deleted", name))
+
+			err := a.deleteLambdaFunction(function.FunctionName)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("lambda function %#q does not exist anymore", name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting lambda function %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted lambda function %#q", name))
+			}
+		}
+
+		if o.NextMarker == nil {
+			break
+		}
+		marker = o.NextMarker
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteLambdaFunction(name *string) error {
+	{
+		var marker *string
+		for {
+			i := &lambda.ListEventSourceMappingsInput{
+				FunctionName: name,
+				Marker:       marker,
+			}
+
+			o, err := a.lambdaClient.ListEventSourceMappings(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, mapping := range o.EventSourceMappings {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting event source mapping %#q of lambda function %#q", *mapping.UUID, *name))
+
+				i := &lambda.DeleteEventSourceMappingInput{
+					UUID: mapping.UUID,
+				}
+				_, err := a.lambdaClient.DeleteEventSourceMapping(i)
+				if IsNotFound(err) || IsInUse(err) {
+					// fall through, the mapping is already being deleted.
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if o.NextMarker == nil {
+				break
+			}
+			marker = o.NextMarker
+		}
+	}
+
+	{
+		var marker *string
+		for {
+			i := &lambda.ListFunctionUrlConfigsInput{
+				FunctionName: name,
+				Marker:       marker,
+			}
+
+			o, err := a.lambdaClient.ListFunctionUrlConfigs(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, config := range o.FunctionUrlConfigs {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting function url config %#q of lambda function %#q", *config.FunctionUrl, *name))
+
+				i := &lambda.DeleteFunctionUrlConfigInput{
+					FunctionName: name,
+					Qualifier:    lambdaQualifier(aws.StringValue(config.FunctionArn)),
+				}
+				_, err := a.lambdaClient.DeleteFunctionUrlConfig(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if o.NextMarker == nil {
+				break
+			}
+			marker = o.NextMarker
+		}
+	}
+
+	{
+		i := &lambda.DeleteFunctionInput{
+			FunctionName: name,
+		}
+		_, err := a.lambdaClient.DeleteFunction(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) lambdaFunctionTags(arn *string) (map[string]string, error) {
+	i := &lambda.ListTagsInput{
+		Resource: arn,
+	}
+	o, err := a.lambdaClient.ListTags(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tags := map[string]string{}
+	for k, v := range o.Tags {
+		if v == nil {
+			continue
+		}
+		tags[k] = *v
+	}
+
+	return tags, nil
+}
+
+func lambdaFunctionShouldBeDeleted(function *lambda.FunctionConfiguration, tags map[string]string) bool {
+	if !isCIResource(aws.StringValue(function.FunctionName)) && !isCITagged(tags) {
+		return false
+	}
+
+	// do not delete recent functions.
+	return !isLambdaFunctionRecent(function)
+}
+
+// isLambdaFunctionRecent returns true if the given function was modified
+// within gracePeriod. Missing or malformed modification times are not
+// considered recent.
+func isLambdaFunctionRecent(function *lambda.FunctionConfiguration) bool {
+	lastModified, err := time.Parse(lambdaTimeFormat, aws.StringValue(function.LastModified))
+	if err != nil {
+		return false
+	}
+
+	return time.Now().UTC().Sub(lastModified) < gracePeriod
+}
+
+// lambdaQualifier returns the alias or version of the given function ARN, e.g.
+// live for arn:aws:lambda:eu-central-1:123456789012:function:ci-1a2b3:live. nil
+// is returned for unqualified ARNs.
+func lambdaQualifier(arn string) *string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 8 {
+		return nil
+	}
+
+	return aws.String(parts[7])
+}
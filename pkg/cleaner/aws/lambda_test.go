@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+)
+
+func TestLambdaFunctionShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		function    *lambda.FunctionConfiguration
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci function should be deleted",
+			function: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("ci-wip-1a2b3-dns"),
+				LastModified: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(lambdaTimeFormat)),
+			},
+			expected: true,
+		},
+		{
+			description: "old function tagged for ci cluster should be deleted",
+			function: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("lifecycle-hook"),
+				LastModified: aws.String("2020-01-02T15:04:05.000+0000"),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recently modified ci function should not be deleted",
+			function: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("ci-wip-1a2b3-dns"),
+				LastModified: aws.String(time.Now().UTC().Format(lambdaTimeFormat)),
+			},
+			expected: false,
+		},
+		{
+			description: "old other function should not be deleted",
+			function: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("lifecycle-hook"),
+				LastModified: aws.String("2020-01-02T15:04:05.000+0000"),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := lambdaFunctionShouldBeDeleted(tc.function, tc.tags)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.function.FunctionName, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanLaunchTemplates deletes the EC2 launch templates created by CI test
+// runs. Launch templates still referenced by an existing Auto Scaling group
+// are kept until the group is deleted.
+func (a *Cleaner) cleanLaunchTemplates() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	refs, err := a.autoScalingReferences()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeLaunchTemplatesInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeLaunchTemplates(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, lt := range o.LaunchTemplates {
+			if !launchTemplateShouldBeDeleted(lt, refs.launchTemplates) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that launch template %#q should be deleted", *lt.LaunchTemplateName))
+
+			i := &ec2.DeleteLaunchTemplateInput{
+				LaunchTemplateId: lt.LaunchTemplateId,
+			}
+			_, err := a.ec2Client.DeleteLaunchTemplate(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("launch template %#q does not exist anymore", *lt.LaunchTemplateName))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting launch template %#q: %#v", *lt.LaunchTemplateName, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted launch template %#q", *lt.LaunchTemplateName))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanLaunchConfigurations deletes the launch configurations created by CI
+// test runs of older releases. Launch configurations still referenced by an
+// existing Auto Scaling group are kept until the group is deleted.
+func (a *Cleaner) cleanLaunchConfigurations() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	refs, err := a.autoScalingReferences()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	configs, err := a.describeLaunchConfigurations()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, lc := range configs {
+		if !launchConfigurationShouldBeDeleted(lc, refs.launchConfigurations) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that launch configuration %#q should be deleted", *lc.LaunchConfigurationName))
+
+		i := &autoscaling.DeleteLaunchConfigurationInput{
+			LaunchConfigurationName: lc.LaunchConfigurationName,
+		}
+		_, err := a.autoScalingClient.DeleteLaunchConfiguration(i)
+		if IsInUse(err) {
+			// the launch configuration got attached to a group in the
+			// meantime.
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("launch configuration %#q is still in use", *lc.LaunchConfigurationName))
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting launch configuration %#q: %#v", *lc.LaunchConfigurationName, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted launch configuration %#q", *lc.LaunchConfigurationName))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func launchTemplateShouldBeDeleted(lt *ec2.LaunchTemplate, referenced map[string]bool) bool {
+	if lt.LaunchTemplateId == nil || lt.LaunchTemplateName == nil {
+		return false
+	}
+
+	if !isCIResource(*lt.LaunchTemplateName) && !isCITagged(ec2Tags(lt.Tags)) {
+		return false
+	}
+
+	// do not delete launch templates which are still used by a group.
+	if referenced[*lt.LaunchTemplateId] || referenced[*lt.LaunchTemplateName] {
+		return false
+	}
+
+	if lt.CreateTime == nil {
+		// bad formed launch template, should be deleted
+		return true
+	}
+
+	// do not delete recent launch templates.
+	if time.Now().UTC().Sub(*lt.CreateTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func launchConfigurationShouldBeDeleted(lc *autoscaling.LaunchConfiguration, referenced map[string]bool) bool {
+	name := aws.StringValue(lc.LaunchConfigurationName)
+
+	if !isCIResource(name) {
+		return false
+	}
+
+	// do not delete launch configurations which are still used by a group.
+	if referenced[name] {
+		return false
+	}
+
+	if lc.CreatedTime == nil {
+		// bad formed launch configuration, should be deleted
+		return true
+	}
+
+	// do not delete recent launch configurations.
+	if time.Now().UTC().Sub(*lc.CreatedTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
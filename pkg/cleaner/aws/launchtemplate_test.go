@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestLaunchTemplateShouldBeDeleted(t *testing.T) {
+	referenced := map[string]bool{
+		"lt-used":         true,
+		"ci-used-by-name": true,
+	}
+
+	tcs := []struct {
+		lt          *ec2.LaunchTemplate
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci launch template should be deleted",
+			lt: &ec2.LaunchTemplate{
+				CreateTime:         aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchTemplateId:   aws.String("lt-1"),
+				LaunchTemplateName: aws.String("ci-wip-1a2b3-worker"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci launch template should not be deleted",
+			lt: &ec2.LaunchTemplate{
+				CreateTime:         aws.Time(time.Now()),
+				LaunchTemplateId:   aws.String("lt-2"),
+				LaunchTemplateName: aws.String("ci-wip-1a2b3-worker"),
+			},
+			expected: false,
+		},
+		{
+			description: "old launch template tagged for ci cluster should be deleted",
+			lt: &ec2.LaunchTemplate{
+				CreateTime:         aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchTemplateId:   aws.String("lt-3"),
+				LaunchTemplateName: aws.String("worker"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "old ci launch template used by a group should not be deleted",
+			lt: &ec2.LaunchTemplate{
+				CreateTime:         aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchTemplateId:   aws.String("lt-used"),
+				LaunchTemplateName: aws.String("ci-wip-1a2b3-worker"),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci launch template used by a group by name should not be deleted",
+			lt: &ec2.LaunchTemplate{
+				CreateTime:         aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchTemplateId:   aws.String("lt-4"),
+				LaunchTemplateName: aws.String("ci-used-by-name"),
+			},
+			expected: false,
+		},
+		{
+			description: "old other launch template should not be deleted",
+			lt: &ec2.LaunchTemplate{
+				CreateTime:         aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchTemplateId:   aws.String("lt-5"),
+				LaunchTemplateName: aws.String("worker"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := launchTemplateShouldBeDeleted(tc.lt, referenced)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.lt.LaunchTemplateName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLaunchConfigurationShouldBeDeleted(t *testing.T) {
+	referenced := map[string]bool{
+		"cluster-ci-used-LaunchConfiguration-1": true,
+	}
+
+	tcs := []struct {
+		lc          *autoscaling.LaunchConfiguration
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci launch configuration should be deleted",
+			lc: &autoscaling.LaunchConfiguration{
+				CreatedTime:             aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchConfigurationName: aws.String("cluster-ci-wip-1a2b3-guest-main-LaunchConfiguration-ABC"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci launch configuration should not be deleted",
+			lc: &autoscaling.LaunchConfiguration{
+				CreatedTime:             aws.Time(time.Now()),
+				LaunchConfigurationName: aws.String("cluster-ci-wip-1a2b3-guest-main-LaunchConfiguration-ABC"),
+			},
+			expected: false,
+		},
+		{
+			description: "ci launch configuration without creation time should be deleted",
+			lc: &autoscaling.LaunchConfiguration{
+				LaunchConfigurationName: aws.String("cluster-ci-wip-1a2b3-guest-main-LaunchConfiguration-ABC"),
+			},
+			expected: true,
+		},
+		{
+			description: "old ci launch configuration used by a group should not be deleted",
+			lc: &autoscaling.LaunchConfiguration{
+				CreatedTime:             aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchConfigurationName: aws.String("cluster-ci-used-LaunchConfiguration-1"),
+			},
+			expected: false,
+		},
+		{
+			description: "old other launch configuration should not be deleted",
+			lc: &autoscaling.LaunchConfiguration{
+				CreatedTime:             aws.Time(time.Now().Add(-2 * time.Hour)),
+				LaunchConfigurationName: aws.String("cluster-abc12-guest-main-LaunchConfiguration-ABC"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := launchConfigurationShouldBeDeleted(tc.lc, referenced)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.lc.LaunchConfigurationName, tc.expected, actual)
+			}
+		})
+	}
+}
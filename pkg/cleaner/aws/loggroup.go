@@ -0,0 +1,299 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanLogGroups deletes the CloudWatch log groups of CI clusters, e.g. of
+// Lambda functions, VPC flow logs or control plane logging. Log groups are
+// retained forever by default, so they accumulate storage costs. Log groups
+// are deleted once the CloudFormation stacks of their cluster are gone or once
+// they exceed the grace period.
+// Optionally metric filters and subscription filters of CI clusters are
+// deleted from the remaining log groups.
+func (a *Cleaner) cleanLogGroups() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stacks, err := a.stacks()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var remaining []*cloudwatchlogs.LogGroup
+	var nextToken *string
+	for {
+		i := &cloudwatchlogs.DescribeLogGroupsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.cloudWatchLogsClient.DescribeLogGroups(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, group := range o.LogGroups {
+			name := *group.LogGroupName
+
+			// log groups are listed without their tags and ListTagsLogGroup
+			// is throttled quickly, so we only fetch them for log groups which
+			// exceed the grace period and have no CI cluster in their name.
+			var tags map[string]string
+			if logGroupCICluster(name, nil) == "" && !isLogGroupRecent(group) {
+				tags, err = a.logGroupTags(name)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of log group %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+			}
+
+			if !logGroupShouldBeDeleted(group, tags, stacks) {
+				remaining = append(remaining, group)
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that log group %#q should be deleted", name))
+
+			i := &cloudwatchlogs.DeleteLogGroupInput{
+				LogGroupName: group.LogGroupName,
+			}
+			_, err := a.cloudWatchLogsClient.DeleteLogGroup(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("log group %#q does not exist anymore", name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting log group %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted log group %#q", name))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	// the filters of deleted log groups are deleted together with them, so we
+	// only have to look at the remaining ones.
+	if a.cleanLogFilters {
+		err := a.deleteLogFilters(remaining)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) logGroupTags(name string) (map[string]string, error) {
+	i := &cloudwatchlogs.ListTagsLogGroupInput{
+		LogGroupName: aws.String(name),
+	}
+	o, err := a.cloudWatchLogsClient.ListTagsLogGroup(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tags := map[string]string{}
+	for k, v := range o.Tags {
+		if v == nil {
+			continue
+		}
+		tags[k] = *v
+	}
+
+	return tags, nil
+}
+
+// deleteLogFilters deletes the metric filters and subscription filters of CI
+// clusters from the given log groups. Each log group only allows a few
+// subscription filters, so leftover ones block new CI runs from subscribing.
+func (a *Cleaner) deleteLogFilters(groups []*cloudwatchlogs.LogGroup) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	{
+		var nextToken *string
+		for {
+			i := &cloudwatchlogs.DescribeMetricFiltersInput{
+				NextToken: nextToken,
+			}
+
+			o, err := a.cloudWatchLogsClient.DescribeMetricFilters(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			for _, filter := range o.MetricFilters {
+				if !metricFilterShouldBeDeleted(filter) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that metric filter %#q of log group %#q should be deleted", *filter.FilterName, *filter.LogGroupName))
+
+				i := &cloudwatchlogs.DeleteMetricFilterInput{
+					FilterName:   filter.FilterName,
+					LogGroupName: filter.LogGroupName,
+				}
+				_, err := a.cloudWatchLogsClient.DeleteMetricFilter(i)
+				if IsNotFound(err) {
+					a.logger.Log("level", "debug", "message", fmt.Sprintf("metric filter %#q of log group %#q does not exist anymore", *filter.FilterName, *filter.LogGroupName))
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					// do not return on error, try to continue deleting.
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting metric filter %#q of log group %#q: %#v", *filter.FilterName, *filter.LogGroupName, err), "stack", fmt.Sprintf("%#v", err))
+				} else {
+					a.logger.Log("level", "info", "message", fmt.Sprintf("deleted metric filter %#q of log group %#q", *filter.FilterName, *filter.LogGroupName))
+				}
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	for _, group := range groups {
+		i := &cloudwatchlogs.DescribeSubscriptionFiltersInput{
+			LogGroupName: group.LogGroupName,
+		}
+		o, err := a.cloudWatchLogsClient.DescribeSubscriptionFilters(i)
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed describing subscription filters of log group %#q: %#v", *group.LogGroupName, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		for _, filter := range o.SubscriptionFilters {
+			if !subscriptionFilterShouldBeDeleted(filter) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that subscription filter %#q of log group %#q should be deleted", *filter.FilterName, *filter.LogGroupName))
+
+			i := &cloudwatchlogs.DeleteSubscriptionFilterInput{
+				FilterName:   filter.FilterName,
+				LogGroupName: filter.LogGroupName,
+			}
+			_, err := a.cloudWatchLogsClient.DeleteSubscriptionFilter(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("subscription filter %#q of log group %#q does not exist anymore", *filter.FilterName, *filter.LogGroupName))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting subscription filter %#q of log group %#q: %#v", *filter.FilterName, *filter.LogGroupName, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted subscription filter %#q of log group %#q", *filter.FilterName, *filter.LogGroupName))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// logGroupShouldBeDeleted returns true if the given log group belongs to a CI
+// cluster and is older than gracePeriod or none of the given stacks belongs to
+// its cluster anymore.
+func logGroupShouldBeDeleted(group *cloudwatchlogs.LogGroup, tags map[string]string, stacks map[string]*cloudformation.Stack) bool {
+	cluster := logGroupCICluster(aws.StringValue(group.LogGroupName), tags)
+	if cluster == "" {
+		return false
+	}
+
+	if !isLogGroupRecent(group) {
+		return true
+	}
+
+	// do not delete recent log groups of clusters which still exist.
+	for name := range stacks {
+		if strings.Contains(name, cluster) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// logGroupCICluster returns the ID of the CI cluster the log group with the
+// given name and tags belongs to. Log group names are paths, e.g.
+// /aws/lambda/<cluster>-<function> or /aws/eks/<cluster>/cluster, so the
+// cluster is taken from any of their segments. An empty string is returned in
+// case the log group does not belong to any CI cluster.
+func logGroupCICluster(name string, tags map[string]string) string {
+	cluster := ciClusterFromTags(tags)
+	if id := ciClusterID(cluster); id != "" {
+		return id
+	} else if cluster != "" {
+		return cluster
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if id := ciClusterID(segment); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+func isLogGroupRecent(group *cloudwatchlogs.LogGroup) bool {
+	return isMillisecondsRecent(group.CreationTime)
+}
+
+func metricFilterShouldBeDeleted(filter *cloudwatchlogs.MetricFilter) bool {
+	if !isCIResource(aws.StringValue(filter.FilterName)) {
+		return false
+	}
+
+	// do not delete recent metric filters.
+	return !isMillisecondsRecent(filter.CreationTime)
+}
+
+func subscriptionFilterShouldBeDeleted(filter *cloudwatchlogs.SubscriptionFilter) bool {
+	// filters are either named for the cluster or subscribe e.g. a Lambda
+	// function of the cluster.
+	arn := aws.StringValue(filter.DestinationArn)
+	destination := arn[strings.LastIndexAny(arn, ":/")+1:]
+	if !isCIResource(aws.StringValue(filter.FilterName)) && !isCIResource(destination) {
+		return false
+	}
+
+	// do not delete recent subscription filters.
+	return !isMillisecondsRecent(filter.CreationTime)
+}
+
+// isMillisecondsRecent returns true if the given creation time in milliseconds
+// since the epoch, as CloudWatch returns it, is within gracePeriod. Missing
+// creation times are not considered recent.
+func isMillisecondsRecent(t *int64) bool {
+	if t == nil {
+		return false
+	}
+
+	createdAt := time.Unix(0, *t*int64(time.Millisecond))
+
+	return time.Now().UTC().Sub(createdAt) < gracePeriod
+}
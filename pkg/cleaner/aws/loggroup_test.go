@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+func milliseconds(t time.Time) *int64 {
+	return aws.Int64(t.UnixNano() / int64(time.Millisecond))
+}
+
+func TestLogGroupShouldBeDeleted(t *testing.T) {
+	stacks := map[string]*cloudformation.Stack{
+		"cluster-ci-wip-1a2b3-tccp": {
+			StackName: aws.String("cluster-ci-wip-1a2b3-tccp"),
+		},
+	}
+
+	tcs := []struct {
+		group       *cloudwatchlogs.LogGroup
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old lambda log group of gone ci cluster should be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now().Add(-2 * time.Hour)),
+				LogGroupName: aws.String("/aws/lambda/ci-wip-4c5d6-route53-manager"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent eks log group of gone ci cluster should be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now()),
+				LogGroupName: aws.String("/aws/eks/ci-wip-4c5d6/cluster"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent lambda log group of existing ci cluster should not be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now()),
+				LogGroupName: aws.String("/aws/lambda/ci-wip-1a2b3-dns-handler"),
+			},
+			expected: false,
+		},
+		{
+			description: "recent eks log group of existing ci cluster should not be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now()),
+				LogGroupName: aws.String("/aws/eks/ci-wip-1a2b3/cluster"),
+			},
+			expected: false,
+		},
+		{
+			description: "old eks log group of existing ci cluster should be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now().Add(-2 * time.Hour)),
+				LogGroupName: aws.String("/aws/eks/ci-wip-1a2b3/cluster"),
+			},
+			expected: true,
+		},
+		{
+			description: "old log group tagged for gone ci cluster should be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now().Add(-2 * time.Hour)),
+				LogGroupName: aws.String("/aws/vpc/flowlogs"),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-4c5d6",
+			},
+			expected: true,
+		},
+		{
+			description: "old log group of ci tooling should not be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now().Add(-2 * time.Hour)),
+				LogGroupName: aws.String("/aws/lambda/ci-cleaner"),
+			},
+			expected: false,
+		},
+		{
+			description: "old other log group should not be deleted",
+			group: &cloudwatchlogs.LogGroup{
+				CreationTime: milliseconds(time.Now().Add(-2 * time.Hour)),
+				LogGroupName: aws.String("/aws/lambda/abc12-route53-manager"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := logGroupShouldBeDeleted(tc.group, tc.tags, stacks)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.group.LogGroupName, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSubscriptionFilterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		filter      *cloudwatchlogs.SubscriptionFilter
+		expected    bool
+		description string
+	}{
+		{
+			description: "old filter named for ci cluster should be deleted",
+			filter: &cloudwatchlogs.SubscriptionFilter{
+				CreationTime:   milliseconds(time.Now().Add(-2 * time.Hour)),
+				DestinationArn: aws.String("arn:aws:kinesis:eu-central-1:123456789012:stream/logs"),
+				FilterName:     aws.String("ci-wip-1a2b3-logs"),
+			},
+			expected: true,
+		},
+		{
+			description: "old filter subscribing ci lambda function should be deleted",
+			filter: &cloudwatchlogs.SubscriptionFilter{
+				CreationTime:   milliseconds(time.Now().Add(-2 * time.Hour)),
+				DestinationArn: aws.String("arn:aws:lambda:eu-central-1:123456789012:function:ci-wip-1a2b3-forwarder"),
+				FilterName:     aws.String("forwarder"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent filter named for ci cluster should not be deleted",
+			filter: &cloudwatchlogs.SubscriptionFilter{
+				CreationTime:   milliseconds(time.Now()),
+				DestinationArn: aws.String("arn:aws:kinesis:eu-central-1:123456789012:stream/logs"),
+				FilterName:     aws.String("ci-wip-1a2b3-logs"),
+			},
+			expected: false,
+		},
+		{
+			description: "old other filter should not be deleted",
+			filter: &cloudwatchlogs.SubscriptionFilter{
+				CreationTime:   milliseconds(time.Now().Add(-2 * time.Hour)),
+				DestinationArn: aws.String("arn:aws:kinesis:eu-central-1:123456789012:stream/logs"),
+				FilterName:     aws.String("logs"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := subscriptionFilterShouldBeDeleted(tc.filter)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.filter.FilterName, tc.expected, actual)
+			}
+		})
+	}
+}
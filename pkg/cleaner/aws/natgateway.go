@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanNatGateways deletes NAT gateways of CI VPCs or tagged for CI clusters.
+// NAT gateways must be fully deleted before their Elastic IPs can be released
+// and their subnets can be deleted. That is why we wait for the deletion and
+// release the Elastic IPs right away, so that the VPC can be cleaned up in the
+// same run.
+func (a *Cleaner) cleanNatGateways() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var gateways []*ec2.NatGateway
+	{
+		var nextToken *string
+		for {
+			i := &ec2.DescribeNatGatewaysInput{
+				NextToken: nextToken,
+			}
+
+			o, err := a.ec2Client.DescribeNatGateways(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			for _, nat := range o.NatGateways {
+				if natGatewayShouldBeDeleted(nat, vpcs) {
+					gateways = append(gateways, nat)
+				}
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	return a.deleteNatGateways(gateways)
+}
+
+// deleteNatGateways deletes the given NAT gateways, waits for their deletion
+// and releases their Elastic IPs.
+func (a *Cleaner) deleteNatGateways(gateways []*ec2.NatGateway) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var deleting []*ec2.NatGateway
+	for _, nat := range gateways {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that nat gateway %#q should be deleted", *nat.NatGatewayId))
+
+		// do not delete gateways again which are already being deleted, but
+		// wait for them to release their Elastic IPs.
+		if aws.StringValue(nat.State) == ec2.NatGatewayStateDeleting {
+			deleting = append(deleting, nat)
+			continue
+		}
+
+		i := &ec2.DeleteNatGatewayInput{
+			NatGatewayId: nat.NatGatewayId,
+		}
+		_, err := a.ec2Client.DeleteNatGateway(i)
+		if IsNotFound(err) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("nat gateway %#q does not exist anymore", *nat.NatGatewayId))
+			continue
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting nat gateway %#q: %#v", *nat.NatGatewayId, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		deleting = append(deleting, nat)
+	}
+
+	if len(deleting) == 0 {
+		if errors.HasErrors() {
+			return errors
+		}
+		return nil
+	}
+
+	a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for %d nat gateways to be deleted", len(deleting)))
+
+	err := a.waitForNatGatewaysDeleted(deleting)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		// the Elastic IPs are still associated, so we leave them for the next
+		// run.
+		a.logger.Log("level", "error", "message", fmt.Sprintf("failed waiting for nat gateways to be deleted: %#v", err), "stack", fmt.Sprintf("%#v", err))
+		return errors
+	}
+
+	for _, nat := range deleting {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("deleted nat gateway %#q", *nat.NatGatewayId))
+
+		for _, addr := range nat.NatGatewayAddresses {
+			if addr.AllocationId == nil {
+				continue
+			}
+
+			i := &ec2.ReleaseAddressInput{
+				AllocationId: addr.AllocationId,
+			}
+			_, err := a.ec2Client.ReleaseAddress(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("elastic ip %#q does not exist anymore", *addr.AllocationId))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue releasing.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed releasing elastic ip %#q of nat gateway %#q: %#v", *addr.AllocationId, *nat.NatGatewayId, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("released elastic ip %#q of nat gateway %#q", *addr.AllocationId, *nat.NatGatewayId))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) waitForNatGatewaysDeleted(gateways []*ec2.NatGateway) error {
+	var ids []*string
+	for _, nat := range gateways {
+		ids = append(ids, nat.NatGatewayId)
+	}
+
+	deleted := func() (bool, error) {
+		i := &ec2.DescribeNatGatewaysInput{
+			NatGatewayIds: ids,
+		}
+		o, err := a.ec2Client.DescribeNatGateways(i)
+		if IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, nat := range o.NatGateways {
+			state := aws.StringValue(nat.State)
+			if state != ec2.NatGatewayStateDeleted && state != ec2.NatGatewayStateFailed {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+
+	err := waitFor(deleted)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func natGatewayShouldBeDeleted(nat *ec2.NatGateway, vpcs map[string]bool) bool {
+	// do not delete gateways that are already deleted.
+	if aws.StringValue(nat.State) == ec2.NatGatewayStateDeleted {
+		return false
+	}
+
+	if !vpcs[aws.StringValue(nat.VpcId)] && !isCITagged(ec2Tags(nat.Tags)) {
+		return false
+	}
+
+	if nat.CreateTime == nil {
+		// bad formed gateway, should be deleted
+		return true
+	}
+
+	// do not delete recent gateways.
+	if time.Now().UTC().Sub(*nat.CreateTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestNatGatewayShouldBeDeleted(t *testing.T) {
+	vpcs := map[string]bool{
+		"vpc-ci": true,
+	}
+
+	tcs := []struct {
+		nat         *ec2.NatGateway
+		expected    bool
+		description string
+	}{
+		{
+			description: "gateway without creation time in ci vpc should be deleted",
+			nat: &ec2.NatGateway{
+				NatGatewayId: aws.String("nat-1"),
+				State:        aws.String("available"),
+				VpcId:        aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent gateway in ci vpc should not be deleted",
+			nat: &ec2.NatGateway{
+				CreateTime:   aws.Time(time.Now()),
+				NatGatewayId: aws.String("nat-2"),
+				State:        aws.String("available"),
+				VpcId:        aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "old gateway in ci vpc should be deleted",
+			nat: &ec2.NatGateway{
+				CreateTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				NatGatewayId: aws.String("nat-3"),
+				State:        aws.String("available"),
+				VpcId:        aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "old gateway in other vpc should not be deleted",
+			nat: &ec2.NatGateway{
+				CreateTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				NatGatewayId: aws.String("nat-4"),
+				State:        aws.String("available"),
+				VpcId:        aws.String("vpc-other"),
+			},
+			expected: false,
+		},
+		{
+			description: "old gateway tagged for ci cluster should be deleted",
+			nat: &ec2.NatGateway{
+				CreateTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				NatGatewayId: aws.String("nat-5"),
+				State:        aws.String("available"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("giantswarm.io/cluster"),
+						Value: aws.String("ci-wip-50a83-d4f51"),
+					},
+				},
+				VpcId: aws.String("vpc-other"),
+			},
+			expected: true,
+		},
+		{
+			description: "deleting gateway in ci vpc should be deleted",
+			nat: &ec2.NatGateway{
+				CreateTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				NatGatewayId: aws.String("nat-6"),
+				State:        aws.String("deleting"),
+				VpcId:        aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "deleted gateway in ci vpc should not be deleted",
+			nat: &ec2.NatGateway{
+				CreateTime:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				NatGatewayId: aws.String("nat-7"),
+				State:        aws.String("deleted"),
+				VpcId:        aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := natGatewayShouldBeDeleted(tc.nat, vpcs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.nat.NatGatewayId, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanNetworkInterfaces deletes detached network interfaces leftover by the
+// AWS VPC CNI in CI VPCs or tagged for CI clusters. These interfaces pin
+// subnets and security groups and therefore block the deletion of the VPC.
+func (a *Cleaner) cleanNetworkInterfaces() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	var available []*ec2.NetworkInterface
+	var detaching []*ec2.NetworkInterface
+	{
+		var nextToken *string
+		for {
+			i := &ec2.DescribeNetworkInterfacesInput{
+				NextToken: nextToken,
+			}
+
+			o, err := a.ec2Client.DescribeNetworkInterfaces(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			for _, eni := range o.NetworkInterfaces {
+				// interfaces which were not created by the AWS VPC CNI do not
+				// have a creation time, so we remember the time we saw them
+				// first.
+				if isCINetworkInterface(eni, vpcs) {
+					tags := ec2Tags(eni.TagSet)
+					_, hasCreatedAt := tags[tagCNICreatedAt]
+					_, hasFirstSeen := tags[tagFirstSeen]
+					if !hasCreatedAt && !hasFirstSeen {
+						err := a.tagFirstSeen(*eni.NetworkInterfaceId)
+						if err != nil {
+							errors.Append(microerror.Mask(err))
+							a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging network interface %#q: %#v", *eni.NetworkInterfaceId, err), "stack", fmt.Sprintf("%#v", err))
+						}
+						continue
+					}
+				}
+
+				if !networkInterfaceShouldBeDeleted(eni, vpcs) {
+					continue
+				}
+
+				if isNetworkInterfaceDetaching(eni) {
+					detaching = append(detaching, eni)
+				} else {
+					available = append(available, eni)
+				}
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	// Interfaces which are still transitioning from in-use can only be deleted
+	// once they became available. In case they don't become available in time
+	// we leave them for the next run.
+	if len(detaching) > 0 {
+		var ids []*string
+		for _, eni := range detaching {
+			ids = append(ids, eni.NetworkInterfaceId)
+		}
+
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for %d network interfaces to be detached", len(detaching)))
+
+		i := &ec2.DescribeNetworkInterfacesInput{
+			NetworkInterfaceIds: ids,
+		}
+		err := a.ec2Client.WaitUntilNetworkInterfaceAvailable(i)
+		if err != nil {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("network interfaces did not get detached: %#v. Skipping deletion.", err))
+		} else {
+			available = append(available, detaching...)
+		}
+	}
+
+	for _, eni := range available {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that network interface %#q should be deleted", *eni.NetworkInterfaceId))
+
+		i := &ec2.DeleteNetworkInterfaceInput{
+			NetworkInterfaceId: eni.NetworkInterfaceId,
+		}
+		_, err := a.ec2Client.DeleteNetworkInterface(i)
+		if IsNotFound(err) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("network interface %#q does not exist anymore", *eni.NetworkInterfaceId))
+		} else if IsInUse(err) {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("network interface %#q got attached again. Skipping deletion.", *eni.NetworkInterfaceId))
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting network interface %#q: %#v", *eni.NetworkInterfaceId, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted network interface %#q", *eni.NetworkInterfaceId))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// isCINetworkInterface returns true if the given interface is detached and
+// belongs to a CI VPC or CI cluster.
+func isCINetworkInterface(eni *ec2.NetworkInterface, vpcs map[string]bool) bool {
+	// do not delete interfaces managed by AWS services, e.g. load balancers or
+	// NAT gateways. They are deleted together with their owner.
+	if aws.BoolValue(eni.RequesterManaged) {
+		return false
+	}
+
+	// do not delete interfaces which are attached.
+	if aws.StringValue(eni.Status) != ec2.NetworkInterfaceStatusAvailable && !isNetworkInterfaceDetaching(eni) {
+		return false
+	}
+
+	return vpcs[aws.StringValue(eni.VpcId)] || isCITagged(ec2Tags(eni.TagSet))
+}
+
+func networkInterfaceShouldBeDeleted(eni *ec2.NetworkInterface, vpcs map[string]bool) bool {
+	if !isCINetworkInterface(eni, vpcs) {
+		return false
+	}
+
+	tags := ec2Tags(eni.TagSet)
+
+	// do not delete recent interfaces. The AWS VPC CNI keeps a pool of
+	// available interfaces for each node.
+	createdAt, err := time.Parse(time.RFC3339, tags[tagCNICreatedAt])
+	if err == nil {
+		return time.Now().UTC().Sub(createdAt) >= gracePeriod
+	}
+
+	return isFirstSeenBeforeGracePeriod(tags)
+}
+
+func isNetworkInterfaceDetaching(eni *ec2.NetworkInterface) bool {
+	if aws.StringValue(eni.Status) == ec2.NetworkInterfaceStatusDetaching {
+		return true
+	}
+	if eni.Attachment != nil && aws.StringValue(eni.Attachment.Status) == ec2.AttachmentStatusDetaching {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestNetworkInterfaceShouldBeDeleted(t *testing.T) {
+	vpcs := map[string]bool{
+		"vpc-ci": true,
+	}
+
+	tcs := []struct {
+		eni         *ec2.NetworkInterface
+		expected    bool
+		description string
+	}{
+		{
+			description: "available interface in ci vpc first seen long ago should be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-1"),
+				Status:             aws.String("available"),
+				TagSet: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "available interface in ci vpc not seen before should not be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-9"),
+				Status:             aws.String("available"),
+				VpcId:              aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "available interface in ci vpc first seen recently should not be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-10"),
+				Status:             aws.String("available"),
+				TagSet: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "available interface in other vpc should not be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-2"),
+				Status:             aws.String("available"),
+				VpcId:              aws.String("vpc-other"),
+			},
+			expected: false,
+		},
+		{
+			description: "available interface tagged for ci cluster should be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-3"),
+				Status:             aws.String("available"),
+				TagSet: []*ec2.Tag{
+					{
+						Key:   aws.String("cluster.k8s.amazonaws.com/name"),
+						Value: aws.String("ci-wip-50a83-d4f51"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-other"),
+			},
+			expected: true,
+		},
+		{
+			description: "in-use interface in ci vpc should not be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-4"),
+				Status:             aws.String("in-use"),
+				VpcId:              aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "detaching interface in ci vpc should be deleted",
+			eni: &ec2.NetworkInterface{
+				Attachment: &ec2.NetworkInterfaceAttachment{
+					Status: aws.String("detaching"),
+				},
+				NetworkInterfaceId: aws.String("eni-5"),
+				Status:             aws.String("in-use"),
+				TagSet: []*ec2.Tag{
+					{
+						Key:   aws.String("node.k8s.amazonaws.com/createdAt"),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+		{
+			description: "requester managed interface in ci vpc should not be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-6"),
+				RequesterManaged:   aws.Bool(true),
+				Status:             aws.String("available"),
+				VpcId:              aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "recent interface in ci vpc should not be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-7"),
+				Status:             aws.String("available"),
+				TagSet: []*ec2.Tag{
+					{
+						Key:   aws.String("node.k8s.amazonaws.com/createdAt"),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-ci"),
+			},
+			expected: false,
+		},
+		{
+			description: "old interface in ci vpc should be deleted",
+			eni: &ec2.NetworkInterface{
+				NetworkInterfaceId: aws.String("eni-8"),
+				Status:             aws.String("available"),
+				TagSet: []*ec2.Tag{
+					{
+						Key:   aws.String("node.k8s.amazonaws.com/createdAt"),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-ci"),
+			},
+			expected: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := networkInterfaceShouldBeDeleted(tc.eni, vpcs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.eni.NetworkInterfaceId, tc.expected, actual)
+			}
+		})
+	}
+}
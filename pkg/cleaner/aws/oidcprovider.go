@@ -0,0 +1,193 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanIAMOIDCProviders deletes the IAM OIDC identity providers registered for
+// the service account issuers of CI clusters, e.g. for IRSA. The number of
+// providers per account is limited, so leftover providers break new CI runs
+// eventually. Providers are only deleted once the issuer bucket and the
+// CloudFormation stacks of their cluster are gone.
+func (a *Cleaner) cleanIAMOIDCProviders() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stacks, err := a.stacks()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	buckets := map[string]bool{}
+	{
+		o, err := a.s3Client.ListBuckets(&s3.ListBucketsInput{})
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, bucket := range o.Buckets {
+			buckets[aws.StringValue(bucket.Name)] = true
+		}
+	}
+
+	o, err := a.iamClient.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, entry := range o.OpenIDConnectProviderList {
+		i := &iam.GetOpenIDConnectProviderInput{
+			OpenIDConnectProviderArn: entry.Arn,
+		}
+		provider, err := a.iamClient.GetOpenIDConnectProvider(i)
+		if IsNotFound(err) {
+			continue
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed getting oidc provider %#q: %#v", *entry.Arn, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		if !oidcProviderShouldBeDeleted(provider, stacks, buckets) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that oidc provider %#q should be deleted", *entry.Arn))
+
+		{
+			i := &iam.DeleteOpenIDConnectProviderInput{
+				OpenIDConnectProviderArn: entry.Arn,
+			}
+			_, err := a.iamClient.DeleteOpenIDConnectProvider(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("oidc provider %#q does not exist anymore", *entry.Arn))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting oidc provider %#q: %#v", *entry.Arn, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted oidc provider %#q", *entry.Arn))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func oidcProviderShouldBeDeleted(provider *iam.GetOpenIDConnectProviderOutput, stacks map[string]*cloudformation.Stack, buckets map[string]bool) bool {
+	url := aws.StringValue(provider.Url)
+
+	cluster := oidcProviderCICluster(url, iamTags(provider.Tags))
+	if cluster == "" {
+		return false
+	}
+
+	// do not delete providers whose issuer is still served.
+	bucket := oidcIssuerBucket(url)
+	if bucket != "" && buckets[bucket] {
+		return false
+	}
+
+	// the cluster may be taken from a name derived from its ID, e.g. the
+	// issuer bucket ci-1a2b3-g8s-irsa, which stack names do not contain.
+	if id := ciClusterID(cluster); id != "" {
+		cluster = id
+	}
+
+	// do not delete providers of clusters which still exist.
+	for name := range stacks {
+		if strings.Contains(name, cluster) {
+			return false
+		}
+	}
+
+	if provider.CreateDate == nil {
+		// bad formed provider, should be deleted
+		return true
+	}
+
+	// do not delete recent providers.
+	if time.Now().UTC().Sub(*provider.CreateDate) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// oidcProviderCICluster returns the name of the CI cluster the OIDC provider
+// with the given issuer URL and tags belongs to. The cluster is taken from the
+// tags, or from the issuer bucket, host or path of the URL. An empty string is
+// returned in case the provider does not belong to any CI cluster.
+func oidcProviderCICluster(url string, tags map[string]string) string {
+	cluster := ciClusterFromTags(tags)
+	if cluster != "" {
+		return cluster
+	}
+
+	bucket := oidcIssuerBucket(url)
+	if isCIResource(bucket) {
+		return bucket
+	}
+
+	url = strings.TrimPrefix(url, "https://")
+	parts := strings.SplitN(url, "/", 2)
+	for _, label := range strings.Split(parts[0], ".") {
+		if isCIResource(label) {
+			return label
+		}
+	}
+	if len(parts) > 1 {
+		for _, segment := range strings.Split(parts[1], "/") {
+			if isCIResource(segment) {
+				return segment
+			}
+		}
+	}
+
+	return ""
+}
+
+// oidcIssuerBucket returns the name of the S3 bucket serving the issuer with
+// the given URL. Path-style and virtual-hosted-style URLs are supported. An
+// empty string is returned in case the issuer is not served from S3, e.g. via
+// CloudFront.
+func oidcIssuerBucket(url string) string {
+	url = strings.TrimPrefix(url, "https://")
+	parts := strings.SplitN(url, "/", 2)
+	host := parts[0]
+
+	if !strings.HasSuffix(host, ".amazonaws.com") && !strings.HasSuffix(host, ".amazonaws.com.cn") {
+		return ""
+	}
+
+	// path-style, e.g. s3.eu-central-1.amazonaws.com/bucket.
+	if strings.HasPrefix(host, "s3.") || strings.HasPrefix(host, "s3-") {
+		if len(parts) < 2 {
+			return ""
+		}
+		return strings.SplitN(parts[1], "/", 2)[0]
+	}
+
+	// virtual-hosted-style, e.g. bucket.s3.eu-central-1.amazonaws.com.
+	for _, sep := range []string{".s3.", ".s3-"} {
+		if i := strings.Index(host, sep); i > 0 {
+			return host[:i]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,160 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func TestOIDCProviderShouldBeDeleted(t *testing.T) {
+	stacks := map[string]*cloudformation.Stack{
+		"cluster-ci-alive-tccp": {
+			StackName: aws.String("cluster-ci-alive-tccp"),
+		},
+		"cluster-ci-wip-1a2b3-tccp": {
+			StackName: aws.String("cluster-ci-wip-1a2b3-tccp"),
+		},
+	}
+	buckets := map[string]bool{
+		"ci-served-g8s-irsa": true,
+	}
+
+	tcs := []struct {
+		provider    *iam.GetOpenIDConnectProviderOutput
+		expected    bool
+		description string
+	}{
+		{
+			description: "old provider of gone issuer bucket should be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Url:        aws.String("s3.eu-central-1.amazonaws.com/ci-gone-g8s-irsa"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent provider of gone issuer bucket should not be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now()),
+				Url:        aws.String("s3.eu-central-1.amazonaws.com/ci-gone-g8s-irsa"),
+			},
+			expected: false,
+		},
+		{
+			description: "old provider of existing issuer bucket should not be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Url:        aws.String("ci-served-g8s-irsa.s3.eu-central-1.amazonaws.com"),
+			},
+			expected: false,
+		},
+		{
+			description: "old provider of existing cluster should not be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Url:        aws.String("irsa.ci-alive.k8s.gauss.eu-central-1.aws.gigantic.io"),
+			},
+			expected: false,
+		},
+		{
+			description: "old provider of gone issuer bucket of existing cluster should not be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Url:        aws.String("s3.eu-central-1.amazonaws.com/ci-wip-1a2b3-g8s-irsa"),
+			},
+			expected: false,
+		},
+		{
+			description: "old provider with host label of existing cluster should not be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Url:        aws.String("ci-wip-1a2b3-oidc-pod-identity.example.com"),
+			},
+			expected: false,
+		},
+		{
+			description: "old cloudfront provider tagged for gone ci cluster should be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Tags: []*iam.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-gone"),
+					},
+				},
+				Url: aws.String("d1a2b3c4d5e6f7.cloudfront.net"),
+			},
+			expected: true,
+		},
+		{
+			description: "old provider of other cluster should not be deleted",
+			provider: &iam.GetOpenIDConnectProviderOutput{
+				CreateDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Url:        aws.String("oidc.eks.eu-central-1.amazonaws.com/id/ABCDEF0123456789"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := oidcProviderShouldBeDeleted(tc.provider, stacks, buckets)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.provider.Url, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOIDCIssuerBucket(t *testing.T) {
+	tcs := []struct {
+		url         string
+		expected    string
+		description string
+	}{
+		{
+			description: "path-style url",
+			url:         "s3.eu-central-1.amazonaws.com/ci-wip-1a2b3-g8s-irsa",
+			expected:    "ci-wip-1a2b3-g8s-irsa",
+		},
+		{
+			description: "path-style url with scheme and path",
+			url:         "https://s3-eu-west-1.amazonaws.com/ci-wip-1a2b3-g8s-irsa/issuer",
+			expected:    "ci-wip-1a2b3-g8s-irsa",
+		},
+		{
+			description: "virtual-hosted-style url",
+			url:         "ci-wip-1a2b3-g8s-irsa.s3.eu-central-1.amazonaws.com",
+			expected:    "ci-wip-1a2b3-g8s-irsa",
+		},
+		{
+			description: "china region url",
+			url:         "s3.cn-north-1.amazonaws.com.cn/ci-wip-1a2b3-g8s-irsa",
+			expected:    "ci-wip-1a2b3-g8s-irsa",
+		},
+		{
+			description: "cloudfront url",
+			url:         "d1a2b3c4d5e6f7.cloudfront.net",
+			expected:    "",
+		},
+		{
+			description: "eks url",
+			url:         "oidc.eks.eu-central-1.amazonaws.com/id/ABCDEF0123456789",
+			expected:    "",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := oidcIssuerBucket(tc.url)
+
+			if tc.expected != actual {
+				t.Errorf("getting issuer bucket of %q, want %q, got %q", tc.url, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// peeringRequestLifetime is the time a VPC peering connection request
+	// stays in pending-acceptance until it expires.
+	peeringRequestLifetime = 7 * 24 * time.Hour
+)
+
+// cleanVPCPeeringConnections deletes VPC peering connections leftover by e2e
+// tests on the control plane. Peerings to VPCs which do not exist anymore and
+// peering requests which were never accepted are deleted. Routes in the route
+// tables of the control plane VPC pointing to these peerings are deleted as
+// well.
+func (a *Cleaner) cleanVPCPeeringConnections() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.describeVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	peerings, err := a.describeVPCPeeringConnections()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	stale := map[string]bool{}
+	peeredVPCs := map[string]bool{}
+	for _, p := range peerings {
+		if !isCITagged(ec2Tags(p.Tags)) {
+			continue
+		}
+
+		// peerings in a final state cannot be deleted anymore, but their
+		// routes are still left.
+		if isVPCPeeringConnectionGone(p) {
+			stale[*p.VpcPeeringConnectionId] = true
+			addPeeredVPCs(peeredVPCs, p)
+			continue
+		}
+
+		if !vpcPeeringConnectionShouldBeDeleted(p, vpcs) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that vpc peering connection %#q should be deleted", *p.VpcPeeringConnectionId))
+
+		i := &ec2.DeleteVpcPeeringConnectionInput{
+			VpcPeeringConnectionId: p.VpcPeeringConnectionId,
+		}
+		_, err := a.ec2Client.DeleteVpcPeeringConnection(i)
+		if IsNotFound(err) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("vpc peering connection %#q does not exist anymore", *p.VpcPeeringConnectionId))
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpc peering connection %#q: %#v", *p.VpcPeeringConnectionId, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted vpc peering connection %#q", *p.VpcPeeringConnectionId))
+		}
+
+		stale[*p.VpcPeeringConnectionId] = true
+		addPeeredVPCs(peeredVPCs, p)
+	}
+
+	err = a.deletePeeringRoutes(stale, peeredVPCs)
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) describeVPCPeeringConnections() ([]*ec2.VpcPeeringConnection, error) {
+	var peerings []*ec2.VpcPeeringConnection
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeVpcPeeringConnectionsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeVpcPeeringConnections(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		peerings = append(peerings, o.VpcPeeringConnections...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return peerings, nil
+}
+
+// deletePeeringRoutes deletes the routes pointing to the given stale peerings
+// from the route tables of the given peered VPCs.
+func (a *Cleaner) deletePeeringRoutes(stale map[string]bool, vpcs map[string]bool) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	if len(stale) == 0 || len(vpcs) == 0 {
+		return nil
+	}
+
+	var vpcIDs []*string
+	for id := range vpcs {
+		vpcIDs = append(vpcIDs, aws.String(id))
+	}
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeRouteTablesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("vpc-id"),
+					Values: vpcIDs,
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeRouteTables(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, routeTable := range o.RouteTables {
+			for _, route := range routeTable.Routes {
+				id := aws.StringValue(route.VpcPeeringConnectionId)
+				if !stale[id] {
+					continue
+				}
+
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("deleting route of route table %#q pointing to vpc peering connection %#q", *routeTable.RouteTableId, id))
+
+				i := &ec2.DeleteRouteInput{
+					DestinationCidrBlock:     route.DestinationCidrBlock,
+					DestinationIpv6CidrBlock: route.DestinationIpv6CidrBlock,
+					DestinationPrefixListId:  route.DestinationPrefixListId,
+					RouteTableId:             routeTable.RouteTableId,
+				}
+				_, err := a.ec2Client.DeleteRoute(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					// do not return on error, try to continue deleting.
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting route of route table %#q pointing to vpc peering connection %#q: %#v", *routeTable.RouteTableId, id, err), "stack", fmt.Sprintf("%#v", err))
+				} else {
+					a.logger.Log("level", "info", "message", fmt.Sprintf("deleted route of route table %#q pointing to vpc peering connection %#q", *routeTable.RouteTableId, id))
+				}
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// addPeeredVPCs adds the IDs of the requester and accepter VPC of the given
+// peering to the given VPC IDs.
+func addPeeredVPCs(vpcs map[string]bool, p *ec2.VpcPeeringConnection) {
+	for _, info := range []*ec2.VpcPeeringConnectionVpcInfo{p.AccepterVpcInfo, p.RequesterVpcInfo} {
+		if info != nil && info.VpcId != nil {
+			vpcs[*info.VpcId] = true
+		}
+	}
+}
+
+func vpcPeeringConnectionShouldBeDeleted(p *ec2.VpcPeeringConnection, vpcs []*ec2.Vpc) bool {
+	if p.Status == nil {
+		return false
+	}
+
+	switch aws.StringValue(p.Status.Code) {
+	case ec2.VpcPeeringConnectionStateReasonCodePendingAcceptance:
+		// peering requests do not have a creation time, but expire after a
+		// fixed lifetime.
+		if p.ExpirationTime == nil {
+			return true
+		}
+		createdAt := p.ExpirationTime.Add(-peeringRequestLifetime)
+		return time.Now().UTC().Sub(createdAt) >= gracePeriod
+
+	case ec2.VpcPeeringConnectionStateReasonCodeActive:
+		// we only see the VPCs of our own region, so we cannot tell whether
+		// the VPC of an inter-region peering still exists.
+		if p.AccepterVpcInfo != nil && p.RequesterVpcInfo != nil && aws.StringValue(p.AccepterVpcInfo.Region) != aws.StringValue(p.RequesterVpcInfo.Region) {
+			return false
+		}
+
+		return isPeeredVPCGone(p.AccepterVpcInfo, vpcs) || isPeeredVPCGone(p.RequesterVpcInfo, vpcs)
+	}
+
+	return false
+}
+
+// isPeeredVPCGone returns true if the given side of a peering is a VPC of this
+// account which does not exist anymore. VPCs of other accounts cannot be
+// checked and are therefore considered to exist.
+func isPeeredVPCGone(info *ec2.VpcPeeringConnectionVpcInfo, vpcs []*ec2.Vpc) bool {
+	if info == nil {
+		return false
+	}
+
+	var isOwnAccount bool
+	for _, vpc := range vpcs {
+		if aws.StringValue(vpc.VpcId) == aws.StringValue(info.VpcId) {
+			return false
+		}
+		if aws.StringValue(vpc.OwnerId) == aws.StringValue(info.OwnerId) {
+			isOwnAccount = true
+		}
+	}
+
+	return isOwnAccount
+}
+
+func isVPCPeeringConnectionGone(p *ec2.VpcPeeringConnection) bool {
+	if p.Status == nil {
+		return false
+	}
+
+	switch aws.StringValue(p.Status.Code) {
+	case ec2.VpcPeeringConnectionStateReasonCodeDeleted,
+		ec2.VpcPeeringConnectionStateReasonCodeExpired,
+		ec2.VpcPeeringConnectionStateReasonCodeFailed,
+		ec2.VpcPeeringConnectionStateReasonCodeRejected:
+		return true
+	}
+
+	return false
+}
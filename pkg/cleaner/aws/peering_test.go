@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestVPCPeeringConnectionShouldBeDeleted(t *testing.T) {
+	vpcs := []*ec2.Vpc{
+		{
+			OwnerId: aws.String("111111111111"),
+			VpcId:   aws.String("vpc-host"),
+		},
+	}
+
+	tcs := []struct {
+		peering     *ec2.VpcPeeringConnection
+		expected    bool
+		description string
+	}{
+		{
+			description: "active peering to existing vpc should not be deleted",
+			peering: &ec2.VpcPeeringConnection{
+				AccepterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+					OwnerId: aws.String("111111111111"),
+					VpcId:   aws.String("vpc-host"),
+				},
+				RequesterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+					OwnerId: aws.String("222222222222"),
+					VpcId:   aws.String("vpc-guest"),
+				},
+				Status: &ec2.VpcPeeringConnectionStateReason{
+					Code: aws.String("active"),
+				},
+				VpcPeeringConnectionId: aws.String("pcx-1"),
+			},
+			expected: false,
+		},
+		{
+			description: "active peering to deleted vpc of own account should be deleted",
+			peering: &ec2.VpcPeeringConnection{
+				AccepterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+					OwnerId: aws.String("111111111111"),
+					VpcId:   aws.String("vpc-host"),
+				},
+				RequesterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+					OwnerId: aws.String("111111111111"),
+					VpcId:   aws.String("vpc-deleted"),
+				},
+				Status: &ec2.VpcPeeringConnectionStateReason{
+					Code: aws.String("active"),
+				},
+				VpcPeeringConnectionId: aws.String("pcx-2"),
+			},
+			expected: true,
+		},
+		{
+			description: "active inter-region peering should not be deleted",
+			peering: &ec2.VpcPeeringConnection{
+				AccepterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+					OwnerId: aws.String("111111111111"),
+					Region:  aws.String("eu-central-1"),
+					VpcId:   aws.String("vpc-host"),
+				},
+				RequesterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+					OwnerId: aws.String("111111111111"),
+					Region:  aws.String("eu-west-1"),
+					VpcId:   aws.String("vpc-other-region"),
+				},
+				Status: &ec2.VpcPeeringConnectionStateReason{
+					Code: aws.String("active"),
+				},
+				VpcPeeringConnectionId: aws.String("pcx-3"),
+			},
+			expected: false,
+		},
+		{
+			description: "recent pending peering should not be deleted",
+			peering: &ec2.VpcPeeringConnection{
+				ExpirationTime: aws.Time(time.Now().Add(7 * 24 * time.Hour)),
+				Status: &ec2.VpcPeeringConnectionStateReason{
+					Code: aws.String("pending-acceptance"),
+				},
+				VpcPeeringConnectionId: aws.String("pcx-4"),
+			},
+			expected: false,
+		},
+		{
+			description: "old pending peering should be deleted",
+			peering: &ec2.VpcPeeringConnection{
+				ExpirationTime: aws.Time(time.Now().Add(7*24*time.Hour - 2*time.Hour)),
+				Status: &ec2.VpcPeeringConnectionStateReason{
+					Code: aws.String("pending-acceptance"),
+				},
+				VpcPeeringConnectionId: aws.String("pcx-5"),
+			},
+			expected: true,
+		},
+		{
+			description: "failed peering should not be deleted",
+			peering: &ec2.VpcPeeringConnection{
+				Status: &ec2.VpcPeeringConnectionStateReason{
+					Code: aws.String("failed"),
+				},
+				VpcPeeringConnectionId: aws.String("pcx-6"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := vpcPeeringConnectionShouldBeDeleted(tc.peering, vpcs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.peering.VpcPeeringConnectionId, tc.expected, actual)
+			}
+		})
+	}
+}
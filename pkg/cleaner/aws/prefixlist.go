@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// prefixListOwnerAWS is the owner of the prefix lists managed by AWS, e.g.
+	// the ones of S3 or CloudFront.
+	prefixListOwnerAWS = "AWS"
+)
+
+// cleanPrefixLists deletes the customer managed prefix lists of CI clusters.
+// Prefix lists cannot be deleted while they are referenced by security groups
+// or route tables, so this has to run after the VPCs are cleaned. Prefix lists
+// still referenced, e.g. by resources outside of CI VPCs, are kept.
+func (a *Cleaner) cleanPrefixLists() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeManagedPrefixListsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeManagedPrefixLists(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, prefixList := range o.PrefixLists {
+			id := *prefixList.PrefixListId
+
+			// prefix lists do not have a creation time, so we remember the
+			// time we saw them first.
+			if isCIPrefixList(prefixList) {
+				if _, ok := ec2Tags(prefixList.Tags)[tagFirstSeen]; !ok {
+					err := a.tagFirstSeen(id)
+					if err != nil {
+						errors.Append(microerror.Mask(err))
+						a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging prefix list %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+					}
+					continue
+				}
+			}
+
+			if !prefixListShouldBeDeleted(prefixList) {
+				continue
+			}
+
+			referenced, err := a.isPrefixListReferenced(prefixList.PrefixListId)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed getting associations of prefix list %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+			if referenced {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("prefix list %#q is still referenced", id))
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that prefix list %#q should be deleted", id))
+
+			i := &ec2.DeleteManagedPrefixListInput{
+				PrefixListId: prefixList.PrefixListId,
+			}
+			_, err = a.ec2Client.DeleteManagedPrefixList(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("prefix list %#q does not exist anymore", id))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting prefix list %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted prefix list %#q", id))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// isPrefixListReferenced returns true if the given prefix list is referenced by
+// any security group or route table.
+func (a *Cleaner) isPrefixListReferenced(id *string) (bool, error) {
+	i := &ec2.GetManagedPrefixListAssociationsInput{
+		PrefixListId: id,
+	}
+	o, err := a.ec2Client.GetManagedPrefixListAssociations(i)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return len(o.PrefixListAssociations) > 0, nil
+}
+
+func isCIPrefixList(prefixList *ec2.ManagedPrefixList) bool {
+	return isCIResource(aws.StringValue(prefixList.PrefixListName)) || isCITagged(ec2Tags(prefixList.Tags))
+}
+
+func prefixListShouldBeDeleted(prefixList *ec2.ManagedPrefixList) bool {
+	// prefix lists managed by AWS cannot be deleted.
+	if aws.StringValue(prefixList.OwnerId) == prefixListOwnerAWS {
+		return false
+	}
+
+	// do not delete prefix lists that are already being deleted.
+	switch aws.StringValue(prefixList.State) {
+	case ec2.PrefixListStateDeleteInProgress, ec2.PrefixListStateDeleteComplete:
+		return false
+	}
+
+	if !isCIPrefixList(prefixList) {
+		return false
+	}
+
+	return isFirstSeenBeforeGracePeriod(ec2Tags(prefixList.Tags))
+}
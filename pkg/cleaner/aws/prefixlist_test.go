@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestPrefixListShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		prefixList  *ec2.ManagedPrefixList
+		expected    bool
+		description string
+	}{
+		{
+			description: "ci prefix list first seen long ago should be deleted",
+			prefixList: &ec2.ManagedPrefixList{
+				OwnerId:        aws.String("123456789012"),
+				PrefixListId:   aws.String("pl-1a2b3c4d"),
+				PrefixListName: aws.String("ci-wip-1a2b3-nodes"),
+				State:          aws.String(ec2.PrefixListStateCreateComplete),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "prefix list tagged for ci cluster first seen long ago should be deleted",
+			prefixList: &ec2.ManagedPrefixList{
+				OwnerId:        aws.String("123456789012"),
+				PrefixListId:   aws.String("pl-1a2b3c4d"),
+				PrefixListName: aws.String("nodes"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "ci prefix list first seen recently should not be deleted",
+			prefixList: &ec2.ManagedPrefixList{
+				OwnerId:        aws.String("123456789012"),
+				PrefixListId:   aws.String("pl-1a2b3c4d"),
+				PrefixListName: aws.String("ci-wip-1a2b3-nodes"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "ci prefix list being deleted should not be deleted",
+			prefixList: &ec2.ManagedPrefixList{
+				OwnerId:        aws.String("123456789012"),
+				PrefixListId:   aws.String("pl-1a2b3c4d"),
+				PrefixListName: aws.String("ci-wip-1a2b3-nodes"),
+				State:          aws.String(ec2.PrefixListStateDeleteInProgress),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagFirstSeen),
+						Value: aws.String(time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "aws managed prefix list should not be deleted",
+			prefixList: &ec2.ManagedPrefixList{
+				OwnerId:        aws.String(prefixListOwnerAWS),
+				PrefixListId:   aws.String("pl-6ea54007"),
+				PrefixListName: aws.String("com.amazonaws.eu-central-1.s3"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := prefixListShouldBeDeleted(tc.prefixList)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.prefixList.PrefixListId, tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// resolverAutodefinedOwner is the owner of the rules Route53 Resolver
+	// defines itself, e.g. the Internet Resolver rule.
+	resolverAutodefinedOwner = "Route 53 Resolver"
+)
+
+// cleanResolverRules deletes the Route53 Resolver rules of CI clusters, e.g.
+// forwarding rules created by private cluster tests. Rules cannot be deleted
+// while they are associated with VPCs, and they keep their outbound endpoints
+// in use, so they are disassociated from all VPCs first and have to be deleted
+// before the resolver endpoints.
+func (a *Cleaner) cleanResolverRules() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	endpoints, err := a.resolverEndpoints()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	// rules forwarding via endpoints in CI VPCs belong to CI clusters as well.
+	ciEndpoints := map[string]bool{}
+	for _, endpoint := range endpoints {
+		if vpcs[aws.StringValue(endpoint.HostVPCId)] {
+			ciEndpoints[*endpoint.Id] = true
+		}
+	}
+
+	var nextToken *string
+	for {
+		i := &route53resolver.ListResolverRulesInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.route53ResolverClient.ListResolverRules(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, rule := range o.ResolverRules {
+			id := *rule.Id
+
+			// rules are listed without their tags, so we only fetch them for
+			// rules which could be deleted and are not recognized otherwise.
+			var tags map[string]string
+			if isResolverRuleDeletable(rule) && !isCIResource(aws.StringValue(rule.Name)) && !ciEndpoints[aws.StringValue(rule.ResolverEndpointId)] && !isResolverResourceRecent(rule.CreationTime) {
+				tags, err = a.resolverTags(rule.Arn)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of resolver rule %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+			}
+
+			if !resolverRuleShouldBeDeleted(rule, tags, ciEndpoints) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that resolver rule %#q should be deleted", id))
+
+			err := a.deleteResolverRule(rule)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("resolver rule %#q does not exist anymore", id))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting resolver rule %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted resolver rule %#q", id))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// cleanResolverEndpoints deletes the Route53 Resolver endpoints of CI
+// clusters. Endpoints are charged per hour and their network interfaces block
+// the deletion of the subnets of CI VPCs, so this has to run before the VPCs
+// are cleaned and we wait for the endpoints to be gone.
+func (a *Cleaner) cleanResolverEndpoints() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	vpcs, err := a.ciVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	endpoints, err := a.resolverEndpoints()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, endpoint := range endpoints {
+		id := *endpoint.Id
+
+		// endpoints are listed without their tags, so we only fetch them for
+		// endpoints which could be deleted and are not recognized otherwise.
+		var tags map[string]string
+		if !isCIResource(aws.StringValue(endpoint.Name)) && !vpcs[aws.StringValue(endpoint.HostVPCId)] && !isResolverResourceRecent(endpoint.CreationTime) {
+			tags, err = a.resolverTags(endpoint.Arn)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of resolver endpoint %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+		}
+
+		if !resolverEndpointShouldBeDeleted(endpoint, tags, vpcs) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that resolver endpoint %#q should be deleted", id))
+
+		err := a.deleteResolverEndpoint(endpoint.Id)
+		if IsNotFound(err) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("resolver endpoint %#q does not exist anymore", id))
+		} else if IsInUse(err) {
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("resolver endpoint %#q is still in use by resolver rules", id))
+		} else if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting resolver endpoint %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted resolver endpoint %#q", id))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteResolverRule(rule *route53resolver.ResolverRule) error {
+	associations, err := a.resolverRuleAssociations(rule.Id)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, association := range associations {
+		// associations being deleted are waited for below.
+		if aws.StringValue(association.Status) == route53resolver.ResolverRuleAssociationStatusDeleting {
+			continue
+		}
+
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("disassociating resolver rule %#q from vpc %#q", *rule.Id, *association.VPCId))
+
+		i := &route53resolver.DisassociateResolverRuleInput{
+			ResolverRuleId: rule.Id,
+			VPCId:          association.VPCId,
+		}
+		_, err := a.route53ResolverClient.DisassociateResolverRule(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if len(associations) > 0 {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for resolver rule %#q to be disassociated", *rule.Id))
+
+		err := waitFor(func() (bool, error) {
+			associations, err := a.resolverRuleAssociations(rule.Id)
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			return len(associations) == 0, nil
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &route53resolver.DeleteResolverRuleInput{
+			ResolverRuleId: rule.Id,
+		}
+		_, err := a.route53ResolverClient.DeleteResolverRule(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteResolverEndpoint(id *string) error {
+	{
+		i := &route53resolver.DeleteResolverEndpointInput{
+			ResolverEndpointId: id,
+		}
+		_, err := a.route53ResolverClient.DeleteResolverEndpoint(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for resolver endpoint %#q to be deleted", *id))
+
+	err := waitFor(func() (bool, error) {
+		i := &route53resolver.GetResolverEndpointInput{
+			ResolverEndpointId: id,
+		}
+		_, err := a.route53ResolverClient.GetResolverEndpoint(i)
+		if IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// resolverEndpoints returns all Route53 Resolver endpoints of the region.
+func (a *Cleaner) resolverEndpoints() ([]*route53resolver.ResolverEndpoint, error) {
+	var endpoints []*route53resolver.ResolverEndpoint
+
+	var nextToken *string
+	for {
+		i := &route53resolver.ListResolverEndpointsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.route53ResolverClient.ListResolverEndpoints(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		endpoints = append(endpoints, o.ResolverEndpoints...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return endpoints, nil
+}
+
+func (a *Cleaner) resolverRuleAssociations(id *string) ([]*route53resolver.ResolverRuleAssociation, error) {
+	var associations []*route53resolver.ResolverRuleAssociation
+
+	var nextToken *string
+	for {
+		i := &route53resolver.ListResolverRuleAssociationsInput{
+			Filters: []*route53resolver.Filter{
+				{
+					Name:   aws.String("ResolverRuleId"),
+					Values: []*string{id},
+				},
+			},
+			NextToken: nextToken,
+		}
+
+		o, err := a.route53ResolverClient.ListResolverRuleAssociations(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		associations = append(associations, o.ResolverRuleAssociations...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return associations, nil
+}
+
+func (a *Cleaner) resolverTags(arn *string) (map[string]string, error) {
+	tags := map[string]string{}
+
+	var nextToken *string
+	for {
+		i := &route53resolver.ListTagsForResourceInput{
+			NextToken:   nextToken,
+			ResourceArn: arn,
+		}
+
+		o, err := a.route53ResolverClient.ListTagsForResource(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, t := range o.Tags {
+			if t.Key == nil || t.Value == nil {
+				continue
+			}
+			tags[*t.Key] = *t.Value
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return tags, nil
+}
+
+// isResolverRuleDeletable returns false for rules which cannot be deleted by
+// us, e.g. the ones shared with us by other accounts.
+func isResolverRuleDeletable(rule *route53resolver.ResolverRule) bool {
+	if aws.StringValue(rule.OwnerId) == resolverAutodefinedOwner {
+		return false
+	}
+	if aws.StringValue(rule.ShareStatus) == route53resolver.ShareStatusSharedWithMe {
+		return false
+	}
+	if aws.StringValue(rule.Status) == route53resolver.ResolverRuleStatusDeleting {
+		return false
+	}
+
+	return true
+}
+
+func resolverRuleShouldBeDeleted(rule *route53resolver.ResolverRule, tags map[string]string, ciEndpoints map[string]bool) bool {
+	if !isResolverRuleDeletable(rule) {
+		return false
+	}
+
+	if !isCIResource(aws.StringValue(rule.Name)) && !ciEndpoints[aws.StringValue(rule.ResolverEndpointId)] && !isCITagged(tags) {
+		return false
+	}
+
+	if rule.CreationTime == nil {
+		// bad formed rule, should be deleted
+		return true
+	}
+
+	// do not delete recent rules.
+	return !isResolverResourceRecent(rule.CreationTime)
+}
+
+func resolverEndpointShouldBeDeleted(endpoint *route53resolver.ResolverEndpoint, tags map[string]string, vpcs map[string]bool) bool {
+	// do not delete endpoints that are already being deleted.
+	if aws.StringValue(endpoint.Status) == route53resolver.ResolverEndpointStatusDeleting {
+		return false
+	}
+
+	if !isCIResource(aws.StringValue(endpoint.Name)) && !vpcs[aws.StringValue(endpoint.HostVPCId)] && !isCITagged(tags) {
+		return false
+	}
+
+	if endpoint.CreationTime == nil {
+		// bad formed endpoint, should be deleted
+		return true
+	}
+
+	// do not delete recent endpoints.
+	return !isResolverResourceRecent(endpoint.CreationTime)
+}
+
+// isResolverResourceRecent returns true if the given creation time of a
+// Route53 Resolver resource, e.g. 2020-01-02T15:04:05.000Z, is within
+// gracePeriod. Missing or malformed creation times are not considered recent.
+func isResolverResourceRecent(creationTime *string) bool {
+	createdAt, err := time.Parse(time.RFC3339, aws.StringValue(creationTime))
+	if err != nil {
+		return false
+	}
+
+	return time.Now().UTC().Sub(createdAt) < gracePeriod
+}
@@ -0,0 +1,180 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53resolver"
+)
+
+func TestResolverRuleShouldBeDeleted(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().UTC().Format(time.RFC3339)
+
+	tcs := []struct {
+		rule        *route53resolver.ResolverRule
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci rule should be deleted",
+			rule: &route53resolver.ResolverRule{
+				CreationTime: aws.String(old),
+				Id:           aws.String("rslvr-rr-1a2b3c4d"),
+				Name:         aws.String("ci-wip-1a2b3-internal"),
+				ShareStatus:  aws.String(route53resolver.ShareStatusNotShared),
+			},
+			expected: true,
+		},
+		{
+			description: "old rule forwarding via ci endpoint should be deleted",
+			rule: &route53resolver.ResolverRule{
+				CreationTime:       aws.String(old),
+				Id:                 aws.String("rslvr-rr-1a2b3c4d"),
+				Name:               aws.String("internal"),
+				ResolverEndpointId: aws.String("rslvr-out-1a2b3c4d"),
+			},
+			expected: true,
+		},
+		{
+			description: "old rule tagged for ci cluster should be deleted",
+			rule: &route53resolver.ResolverRule{
+				CreationTime: aws.String(old),
+				Id:           aws.String("rslvr-rr-1a2b3c4d"),
+				Name:         aws.String("internal"),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci rule should not be deleted",
+			rule: &route53resolver.ResolverRule{
+				CreationTime: aws.String(recent),
+				Id:           aws.String("rslvr-rr-1a2b3c4d"),
+				Name:         aws.String("ci-wip-1a2b3-internal"),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci rule shared with us should not be deleted",
+			rule: &route53resolver.ResolverRule{
+				CreationTime: aws.String(old),
+				Id:           aws.String("rslvr-rr-1a2b3c4d"),
+				Name:         aws.String("ci-wip-1a2b3-internal"),
+				ShareStatus:  aws.String(route53resolver.ShareStatusSharedWithMe),
+			},
+			expected: false,
+		},
+		{
+			description: "autodefined rule should not be deleted",
+			rule: &route53resolver.ResolverRule{
+				Id:      aws.String("rslvr-autodefined-rr-internet-resolver"),
+				Name:    aws.String("Internet Resolver"),
+				OwnerId: aws.String(resolverAutodefinedOwner),
+			},
+			expected: false,
+		},
+		{
+			description: "old non ci rule should not be deleted",
+			rule: &route53resolver.ResolverRule{
+				CreationTime: aws.String(old),
+				Id:           aws.String("rslvr-rr-1a2b3c4d"),
+				Name:         aws.String("internal"),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+	}
+
+	ciEndpoints := map[string]bool{
+		"rslvr-out-1a2b3c4d": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := resolverRuleShouldBeDeleted(tc.rule, tc.tags, ciEndpoints)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.rule.Id, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestResolverEndpointShouldBeDeleted(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().UTC().Format(time.RFC3339)
+
+	tcs := []struct {
+		endpoint    *route53resolver.ResolverEndpoint
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old endpoint in ci vpc should be deleted",
+			endpoint: &route53resolver.ResolverEndpoint{
+				CreationTime: aws.String(old),
+				HostVPCId:    aws.String("vpc-1a2b3c4d"),
+				Id:           aws.String("rslvr-out-1a2b3c4d"),
+				Status:       aws.String(route53resolver.ResolverEndpointStatusOperational),
+			},
+			expected: true,
+		},
+		{
+			description: "old ci endpoint should be deleted",
+			endpoint: &route53resolver.ResolverEndpoint{
+				CreationTime: aws.String(old),
+				HostVPCId:    aws.String("vpc-5e6f7a8b"),
+				Id:           aws.String("rslvr-in-1a2b3c4d"),
+				Name:         aws.String("ci-wip-1a2b3-inbound"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent endpoint in ci vpc should not be deleted",
+			endpoint: &route53resolver.ResolverEndpoint{
+				CreationTime: aws.String(recent),
+				HostVPCId:    aws.String("vpc-1a2b3c4d"),
+				Id:           aws.String("rslvr-out-1a2b3c4d"),
+			},
+			expected: false,
+		},
+		{
+			description: "endpoint being deleted should not be deleted",
+			endpoint: &route53resolver.ResolverEndpoint{
+				CreationTime: aws.String(old),
+				HostVPCId:    aws.String("vpc-1a2b3c4d"),
+				Id:           aws.String("rslvr-out-1a2b3c4d"),
+				Status:       aws.String(route53resolver.ResolverEndpointStatusDeleting),
+			},
+			expected: false,
+		},
+		{
+			description: "old non ci endpoint should not be deleted",
+			endpoint: &route53resolver.ResolverEndpoint{
+				CreationTime: aws.String(old),
+				HostVPCId:    aws.String("vpc-5e6f7a8b"),
+				Id:           aws.String("rslvr-out-5e6f7a8b"),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+	}
+
+	vpcs := map[string]bool{
+		"vpc-1a2b3c4d": true,
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := resolverEndpointShouldBeDeleted(tc.endpoint, tc.tags, vpcs)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.endpoint.Id, tc.expected, result)
+			}
+		})
+	}
+}
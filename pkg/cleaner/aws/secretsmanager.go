@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanSecrets deletes the Secrets Manager secrets of CI clusters, e.g. written
+// during cluster bootstrap. The secrets may contain sensitive material of the
+// test clusters and their names would be blocked during a recovery window, so
+// they are deleted right away without recovery.
+func (a *Cleaner) cleanSecrets() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &secretsmanager.ListSecretsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.secretsManagerClient.ListSecrets(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, secret := range o.SecretList {
+			if !secretShouldBeDeleted(secret) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that secret %#q should be deleted", *secret.Name))
+
+			i := &secretsmanager.DeleteSecretInput{
+				ForceDeleteWithoutRecovery: aws.Bool(true),
+				SecretId:                   secret.ARN,
+			}
+			_, err := a.secretsManagerClient.DeleteSecret(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("secret %#q does not exist anymore", *secret.Name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting secret %#q: %#v", *secret.Name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted secret %#q", *secret.Name))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func secretShouldBeDeleted(secret *secretsmanager.SecretListEntry) bool {
+	// secrets managed by other AWS services are deleted by them.
+	if secret.OwningService != nil {
+		return false
+	}
+
+	// do not delete secrets which are already scheduled for deletion.
+	if secret.DeletedDate != nil {
+		return false
+	}
+
+	if ciClusterFromPath(aws.StringValue(secret.Name)) == "" && !isCITagged(secretTags(secret.Tags)) {
+		return false
+	}
+
+	if secret.CreatedDate == nil {
+		// bad formed secret, should be deleted
+		return true
+	}
+
+	// do not delete recent secrets.
+	if time.Now().UTC().Sub(*secret.CreatedDate) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+// secretTags converts the given Secrets Manager tags into a map of tag keys and
+// values.
+func secretTags(tags []*secretsmanager.Tag) map[string]string {
+	m := map[string]string{}
+	for _, t := range tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		m[*t.Key] = *t.Value
+	}
+
+	return m
+}
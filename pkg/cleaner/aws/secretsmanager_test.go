@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func TestSecretShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		secret      *secretsmanager.SecretListEntry
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci secret should be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:        aws.String("ci-wip-1a2b3/bootstrap"),
+			},
+			expected: true,
+		},
+		{
+			description: "old secret tagged for ci cluster should be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:        aws.String("bootstrap-token"),
+				Tags: []*secretsmanager.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci secret should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now()),
+				Name:        aws.String("ci-wip-1a2b3/bootstrap"),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci secret scheduled for deletion should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				DeletedDate: aws.Time(time.Now()),
+				Name:        aws.String("ci-wip-1a2b3/bootstrap"),
+			},
+			expected: false,
+		},
+		{
+			description: "old ci secret managed by other service should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate:   aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:          aws.String("ci-wip-1a2b3/bootstrap"),
+				OwningService: aws.String("rds"),
+			},
+			expected: false,
+		},
+		{
+			description: "old shared ci credential should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:        aws.String("giantswarm/ci-pipeline/github-token"),
+			},
+			expected: false,
+		},
+		{
+			description: "old e2e credential should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:        aws.String("e2e-harness/aws-creds"),
+			},
+			expected: false,
+		},
+		{
+			description: "old other secret should not be deleted",
+			secret: &secretsmanager.SecretListEntry{
+				CreatedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:        aws.String("bootstrap-token"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := secretShouldBeDeleted(tc.secret)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.secret.Name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// snsPendingConfirmation is the ARN of subscriptions which are not
+	// confirmed yet. They cannot be unsubscribed and are deleted together with
+	// the topic.
+	snsPendingConfirmation = "PendingConfirmation"
+)
+
+// cleanSNSTopics deletes the SNS topics of CI clusters, e.g. the ones alarms
+// of monitoring tests notify. Subscriptions of a topic are removed first, so
+// that no subscription of another account is left behind.
+func (a *Cleaner) cleanSNSTopics() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &sns.ListTopicsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.snsClient.ListTopics(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, topic := range o.Topics {
+			arn := *topic.TopicArn
+
+			tags, err := a.snsTopicTags(topic.TopicArn)
+			if IsNotFound(err) {
+				continue
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of sns topic %#q: %#v", arn, err), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			// topics do not have a creation time, so we remember the time we
+			// saw them first.
+			if isCISNSTopic(arn, tags) {
+				if _, ok := tags[tagFirstSeen]; !ok {
+					err := a.tagSNSTopicFirstSeen(topic.TopicArn)
+					if err != nil {
+						errors.Append(microerror.Mask(err))
+						a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging sns topic %#q: %#v", arn, err), "stack", fmt.Sprintf("%#v", err))
+					}
+					continue
+				}
+			}
+
+			if !snsTopicShouldBeDeleted(arn, tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sns topic %#q should be deleted", arn))
+
+			err = a.deleteSNSTopic(topic.TopicArn)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("sns topic %#q does not exist anymore", arn))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sns topic %#q: %#v", arn, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted sns topic %#q", arn))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteSNSTopic(arn *string) error {
+	var nextToken *string
+	for {
+		i := &sns.ListSubscriptionsByTopicInput{
+			NextToken: nextToken,
+			TopicArn:  arn,
+		}
+
+		o, err := a.snsClient.ListSubscriptionsByTopic(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, subscription := range o.Subscriptions {
+			if aws.StringValue(subscription.SubscriptionArn) == snsPendingConfirmation {
+				continue
+			}
+
+			a.logger.Log("level", "debug", "message", fmt.Sprintf("removing subscription %#q of sns topic %#q", *subscription.SubscriptionArn, *arn))
+
+			i := &sns.UnsubscribeInput{
+				SubscriptionArn: subscription.SubscriptionArn,
+			}
+			_, err := a.snsClient.Unsubscribe(i)
+			if IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	{
+		i := &sns.DeleteTopicInput{
+			TopicArn: arn,
+		}
+		_, err := a.snsClient.DeleteTopic(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) snsTopicTags(arn *string) (map[string]string, error) {
+	i := &sns.ListTagsForResourceInput{
+		ResourceArn: arn,
+	}
+	o, err := a.snsClient.ListTagsForResource(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tags := map[string]string{}
+	for _, t := range o.Tags {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		tags[*t.Key] = *t.Value
+	}
+
+	return tags, nil
+}
+
+// tagSNSTopicFirstSeen tags the given SNS topic with the current time, so that
+// we can tell its age in later runs.
+func (a *Cleaner) tagSNSTopicFirstSeen(arn *string) error {
+	i := &sns.TagResourceInput{
+		ResourceArn: arn,
+		Tags: []*sns.Tag{
+			{
+				Key:   aws.String(tagFirstSeen),
+				Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+			},
+		},
+	}
+	_, err := a.snsClient.TagResource(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func isCISNSTopic(arn string, tags map[string]string) bool {
+	return isCIResource(snsTopicName(arn)) || isCITagged(tags)
+}
+
+func snsTopicShouldBeDeleted(arn string, tags map[string]string) bool {
+	if !isCISNSTopic(arn, tags) {
+		return false
+	}
+
+	return isFirstSeenBeforeGracePeriod(tags)
+}
+
+// snsTopicName returns the name of the SNS topic with the given ARN, e.g.
+// ci-1a2b3-alerts for arn:aws:sns:eu-central-1:123456789012:ci-1a2b3-alerts.
+func snsTopicName(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSNSTopicShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		arn         string
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "ci topic first seen long ago should be deleted",
+			arn:         "arn:aws:sns:eu-central-1:123456789012:ci-wip-1a2b3-alerts",
+			tags: map[string]string{
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: true,
+		},
+		{
+			description: "topic tagged for ci cluster first seen long ago should be deleted",
+			arn:         "arn:aws:sns:eu-central-1:123456789012:alerts",
+			tags: map[string]string{
+				tagCluster:   "ci-wip-1a2b3",
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: true,
+		},
+		{
+			description: "ci topic first seen recently should not be deleted",
+			arn:         "arn:aws:sns:eu-central-1:123456789012:ci-wip-1a2b3-alerts",
+			tags: map[string]string{
+				tagFirstSeen: time.Now().UTC().Format(time.RFC3339),
+			},
+			expected: false,
+		},
+		{
+			description: "ci topic not seen before should not be deleted",
+			arn:         "arn:aws:sns:eu-central-1:123456789012:ci-wip-1a2b3-alerts",
+			tags:        map[string]string{},
+			expected:    false,
+		},
+		{
+			description: "non ci topic should not be deleted",
+			arn:         "arn:aws:sns:eu-central-1:123456789012:alerts",
+			tags: map[string]string{
+				tagFirstSeen: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := snsTopicShouldBeDeleted(tc.arn, tc.tags)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.arn, tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// maxCancelSpotInstanceRequests is the number of spot instance requests
+	// which are cancelled in a single request.
+	maxCancelSpotInstanceRequests = 100
+	// maxCancelSpotFleetRequests is the maximum number of spot fleet requests
+	// which can be cancelled in a single request.
+	maxCancelSpotFleetRequests = 100
+)
+
+// cleanSpotInstanceRequests cancels the spot instance requests of CI clusters,
+// e.g. created by spot node pool tests. Persistent requests keep launching new
+// instances after the cluster is gone, so they are cancelled and the instances
+// they launched are terminated.
+func (a *Cleaner) cleanSpotInstanceRequests() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var requests []*ec2.SpotInstanceRequest
+	{
+		var nextToken *string
+		for {
+			i := &ec2.DescribeSpotInstanceRequestsInput{
+				NextToken: nextToken,
+			}
+
+			o, err := a.ec2Client.DescribeSpotInstanceRequests(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			for _, request := range o.SpotInstanceRequests {
+				if !spotInstanceRequestShouldBeDeleted(request) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that spot instance request %#q should be cancelled", *request.SpotInstanceRequestId))
+
+				requests = append(requests, request)
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	for len(requests) > 0 {
+		n := len(requests)
+		if n > maxCancelSpotInstanceRequests {
+			n = maxCancelSpotInstanceRequests
+		}
+
+		var ids []*string
+		var instanceIDs []*string
+		for _, request := range requests[:n] {
+			ids = append(ids, request.SpotInstanceRequestId)
+			if request.InstanceId != nil {
+				instanceIDs = append(instanceIDs, request.InstanceId)
+			}
+		}
+		requests = requests[n:]
+
+		i := &ec2.CancelSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: ids,
+		}
+		o, err := a.ec2Client.CancelSpotInstanceRequests(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue cancelling.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed cancelling spot instance requests %s: %#v", strings.Join(aws.StringValueSlice(ids), ", "), err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		for _, request := range o.CancelledSpotInstanceRequests {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("cancelled spot instance request %#q", *request.SpotInstanceRequestId))
+		}
+
+		// cancelling a spot instance request does not terminate the instance
+		// it launched.
+		if len(instanceIDs) > 0 {
+			err := a.terminateSpotInstances(instanceIDs)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue cancelling.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed terminating instances %s: %#v", strings.Join(aws.StringValueSlice(instanceIDs), ", "), err), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// terminateSpotInstances terminates the given instances launched by spot
+// instance requests. A single instance which does not exist anymore fails the
+// whole request, so in that case the instances are terminated one by one.
+func (a *Cleaner) terminateSpotInstances(ids []*string) error {
+	i := &ec2.TerminateInstancesInput{
+		InstanceIds: ids,
+	}
+	_, err := a.ec2Client.TerminateInstances(i)
+	if IsNotFound(err) && len(ids) > 1 {
+		errors := &errorcollection.ErrorCollection{}
+
+		for _, id := range ids {
+			err := a.terminateSpotInstances([]*string{id})
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+			}
+		}
+
+		if errors.HasErrors() {
+			return errors
+		}
+		return nil
+	} else if IsNotFound(err) {
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("instance %#q does not exist anymore", *ids[0]))
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, id := range ids {
+		a.logger.Log("level", "info", "message", fmt.Sprintf("terminated instance %#q", *id))
+	}
+
+	return nil
+}
+
+// cleanSpotFleetRequests cancels the spot fleet requests of CI clusters and
+// terminates their instances, so that the fleets do not keep launching new
+// instances after the cluster is gone.
+func (a *Cleaner) cleanSpotFleetRequests() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var ids []*string
+	{
+		var nextToken *string
+		for {
+			i := &ec2.DescribeSpotFleetRequestsInput{
+				NextToken: nextToken,
+			}
+
+			o, err := a.ec2Client.DescribeSpotFleetRequests(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			for _, request := range o.SpotFleetRequestConfigs {
+				if !spotFleetRequestShouldBeDeleted(request) {
+					continue
+				}
+
+				a.logger.Log("level", "info", "message", fmt.Sprintf("found that spot fleet request %#q should be cancelled", *request.SpotFleetRequestId))
+
+				ids = append(ids, request.SpotFleetRequestId)
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxCancelSpotFleetRequests {
+			n = maxCancelSpotFleetRequests
+		}
+
+		i := &ec2.CancelSpotFleetRequestsInput{
+			SpotFleetRequestIds: ids[:n],
+			TerminateInstances:  aws.Bool(true),
+		}
+		o, err := a.ec2Client.CancelSpotFleetRequests(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue cancelling.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed cancelling spot fleet requests %s: %#v", strings.Join(aws.StringValueSlice(ids[:n]), ", "), err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			for _, request := range o.SuccessfulFleetRequests {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("cancelled spot fleet request %#q", *request.SpotFleetRequestId))
+			}
+			for _, request := range o.UnsuccessfulFleetRequests {
+				var code, message string
+				if request.Error != nil {
+					code = aws.StringValue(request.Error.Code)
+					message = aws.StringValue(request.Error.Message)
+				}
+
+				if code == ec2.CancelBatchErrorCodeFleetRequestIdDoesNotExist {
+					a.logger.Log("level", "debug", "message", fmt.Sprintf("spot fleet request %#q does not exist anymore", *request.SpotFleetRequestId))
+					continue
+				}
+
+				err := microerror.Maskf(executionFailedError, "failed cancelling spot fleet request %#q: %s: %s", *request.SpotFleetRequestId, code, message)
+				errors.Append(err)
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed cancelling spot fleet request %#q: %#v", *request.SpotFleetRequestId, err), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		ids = ids[n:]
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func spotInstanceRequestShouldBeDeleted(request *ec2.SpotInstanceRequest) bool {
+	// only open and active requests can launch instances.
+	switch aws.StringValue(request.State) {
+	case ec2.SpotInstanceStateOpen, ec2.SpotInstanceStateActive:
+	default:
+		return false
+	}
+
+	if !isCITagged(ec2Tags(request.Tags)) {
+		return false
+	}
+
+	if request.CreateTime == nil {
+		// bad formed request, should be deleted
+		return true
+	}
+
+	// do not delete recent requests.
+	if time.Now().UTC().Sub(*request.CreateTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
+
+func spotFleetRequestShouldBeDeleted(request *ec2.SpotFleetRequestConfig) bool {
+	// do not cancel requests that are already cancelled or failed.
+	switch aws.StringValue(request.SpotFleetRequestState) {
+	case ec2.BatchStateSubmitted, ec2.BatchStateActive, ec2.BatchStateModifying:
+	default:
+		return false
+	}
+
+	if !isCITagged(ec2Tags(request.Tags)) {
+		return false
+	}
+
+	if request.CreateTime == nil {
+		// bad formed request, should be deleted
+		return true
+	}
+
+	// do not delete recent requests.
+	if time.Now().UTC().Sub(*request.CreateTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestSpotInstanceRequestShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		request     *ec2.SpotInstanceRequest
+		expected    bool
+		description string
+	}{
+		{
+			description: "old active ci request should be deleted",
+			request: &ec2.SpotInstanceRequest{
+				CreateTime:            aws.Time(time.Now().Add(-2 * time.Hour)),
+				SpotInstanceRequestId: aws.String("sir-1a2b3c4d"),
+				State:                 aws.String(ec2.SpotInstanceStateActive),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci request should not be deleted",
+			request: &ec2.SpotInstanceRequest{
+				CreateTime:            aws.Time(time.Now()),
+				SpotInstanceRequestId: aws.String("sir-1a2b3c4d"),
+				State:                 aws.String(ec2.SpotInstanceStateOpen),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "cancelled ci request should not be deleted",
+			request: &ec2.SpotInstanceRequest{
+				CreateTime:            aws.Time(time.Now().Add(-2 * time.Hour)),
+				SpotInstanceRequestId: aws.String("sir-1a2b3c4d"),
+				State:                 aws.String(ec2.SpotInstanceStateCancelled),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old non ci request should not be deleted",
+			request: &ec2.SpotInstanceRequest{
+				CreateTime:            aws.Time(time.Now().Add(-2 * time.Hour)),
+				SpotInstanceRequestId: aws.String("sir-1a2b3c4d"),
+				State:                 aws.String(ec2.SpotInstanceStateActive),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := spotInstanceRequestShouldBeDeleted(tc.request)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.request.SpotInstanceRequestId, tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestSpotFleetRequestShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		request     *ec2.SpotFleetRequestConfig
+		expected    bool
+		description string
+	}{
+		{
+			description: "old active ci fleet should be deleted",
+			request: &ec2.SpotFleetRequestConfig{
+				CreateTime:            aws.Time(time.Now().Add(-2 * time.Hour)),
+				SpotFleetRequestId:    aws.String("sfr-1a2b3c4d"),
+				SpotFleetRequestState: aws.String(ec2.BatchStateActive),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci fleet should not be deleted",
+			request: &ec2.SpotFleetRequestConfig{
+				CreateTime:            aws.Time(time.Now()),
+				SpotFleetRequestId:    aws.String("sfr-1a2b3c4d"),
+				SpotFleetRequestState: aws.String(ec2.BatchStateActive),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "ci fleet already being cancelled should not be deleted",
+			request: &ec2.SpotFleetRequestConfig{
+				CreateTime:            aws.Time(time.Now().Add(-2 * time.Hour)),
+				SpotFleetRequestId:    aws.String("sfr-1a2b3c4d"),
+				SpotFleetRequestState: aws.String(ec2.BatchStateCancelledTerminating),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			description: "old non ci fleet should not be deleted",
+			request: &ec2.SpotFleetRequestConfig{
+				CreateTime:            aws.Time(time.Now().Add(-2 * time.Hour)),
+				SpotFleetRequestId:    aws.String("sfr-1a2b3c4d"),
+				SpotFleetRequestState: aws.String(ec2.BatchStateActive),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			result := spotFleetRequestShouldBeDeleted(tc.request)
+			if result != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.request.SpotFleetRequestId, tc.expected, result)
+			}
+		})
+	}
+}
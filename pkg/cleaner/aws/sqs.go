@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanSQSQueues deletes the SQS queues of CI clusters, e.g. the queue
+// aws-node-termination-handler processes in queue mode. The EventBridge rules
+// sending to these queues are deleted before by cleanEventBridgeRules.
+func (a *Cleaner) cleanSQSQueues() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var nextToken *string
+	for {
+		i := &sqs.ListQueuesInput{
+			// queues are only paginated in case a maximum is given.
+			MaxResults: aws.Int64(1000),
+			NextToken:  nextToken,
+		}
+
+		o, err := a.sqsClient.ListQueues(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, url := range o.QueueUrls {
+			name := sqsQueueName(*url)
+
+			var attributes map[string]*string
+			{
+				i := &sqs.GetQueueAttributesInput{
+					AttributeNames: []*string{
+						aws.String(sqs.QueueAttributeNameCreatedTimestamp),
+					},
+					QueueUrl: url,
+				}
+				o, err := a.sqsClient.GetQueueAttributes(i)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed getting attributes of sqs queue %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+
+				attributes = o.Attributes
+			}
+
+			// queues are listed without their tags, so we only fetch them for
+			// queues which could be deleted and are not recognized by name.
+			var tags map[string]string
+			if !isCIResource(name) && !isSQSQueueRecent(attributes) {
+				i := &sqs.ListQueueTagsInput{
+					QueueUrl: url,
+				}
+				o, err := a.sqsClient.ListQueueTags(i)
+				if IsNotFound(err) {
+					continue
+				} else if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of sqs queue %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+
+				tags = map[string]string{}
+				for k, v := range o.Tags {
+					if v == nil {
+						continue
+					}
+					tags[k] = *v
+				}
+			}
+
+			if !sqsQueueShouldBeDeleted(*url, attributes, tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that sqs queue %#q should be deleted", name))
+
+			i := &sqs.DeleteQueueInput{
+				QueueUrl: url,
+			}
+			_, err := a.sqsClient.DeleteQueue(i)
+			if IsNotFound(err) {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("sqs queue %#q does not exist anymore", name))
+			} else if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue deleting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting sqs queue %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted sqs queue %#q", name))
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func sqsQueueShouldBeDeleted(url string, attributes map[string]*string, tags map[string]string) bool {
+	if !isCIResource(sqsQueueName(url)) && !isCITagged(tags) {
+		return false
+	}
+
+	if attributes[sqs.QueueAttributeNameCreatedTimestamp] == nil {
+		// bad formed queue, should be deleted
+		return true
+	}
+
+	// do not delete recent queues.
+	return !isSQSQueueRecent(attributes)
+}
+
+// isSQSQueueRecent returns true if the creation time of the queue with the
+// given attributes, given in seconds since the epoch, is within gracePeriod.
+// Missing or malformed creation times are not considered recent.
+func isSQSQueueRecent(attributes map[string]*string) bool {
+	seconds, err := strconv.ParseInt(aws.StringValue(attributes[sqs.QueueAttributeNameCreatedTimestamp]), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Now().UTC().Sub(time.Unix(seconds, 0)) < gracePeriod
+}
+
+// sqsQueueName returns the name of the queue with the given URL, e.g.
+// ci-1a2b3-nth for https://sqs.eu-central-1.amazonaws.com/123456789012/ci-1a2b3-nth.
+func sqsQueueName(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
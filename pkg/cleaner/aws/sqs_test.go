@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func TestSQSQueueShouldBeDeleted(t *testing.T) {
+	old := aws.String(strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix(), 10))
+	recent := aws.String(strconv.FormatInt(time.Now().Unix(), 10))
+
+	tcs := []struct {
+		url         string
+		attributes  map[string]*string
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci queue should be deleted",
+			url:         "https://sqs.eu-central-1.amazonaws.com/123456789012/ci-wip-1a2b3-nth",
+			attributes: map[string]*string{
+				sqs.QueueAttributeNameCreatedTimestamp: old,
+			},
+			expected: true,
+		},
+		{
+			description: "old queue tagged for ci cluster should be deleted",
+			url:         "https://sqs.eu-central-1.amazonaws.com/123456789012/nth-queue",
+			attributes: map[string]*string{
+				sqs.QueueAttributeNameCreatedTimestamp: old,
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci queue should not be deleted",
+			url:         "https://sqs.eu-central-1.amazonaws.com/123456789012/ci-wip-1a2b3-nth",
+			attributes: map[string]*string{
+				sqs.QueueAttributeNameCreatedTimestamp: recent,
+			},
+			expected: false,
+		},
+		{
+			description: "old other queue should not be deleted",
+			url:         "https://sqs.eu-central-1.amazonaws.com/123456789012/nth-queue",
+			attributes: map[string]*string{
+				sqs.QueueAttributeNameCreatedTimestamp: old,
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := sqsQueueShouldBeDeleted(tc.url, tc.attributes, tc.tags)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", tc.url, tc.expected, actual)
+			}
+		})
+	}
+}
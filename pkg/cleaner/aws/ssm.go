@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// maxDeleteParameters is the maximum number of SSM parameters which can be
+	// deleted in a single request.
+	maxDeleteParameters = 10
+)
+
+// cleanSSMParameters deletes the SSM parameters of CI clusters, e.g. written
+// during cluster bootstrap. Parameters are matched by a CI cluster ID at the
+// start of their path or by their tags, and are only deleted once they were
+// not modified within gracePeriod.
+func (a *Cleaner) cleanSSMParameters() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	var names []*string
+	var nextToken *string
+	for {
+		i := &ssm.DescribeParametersInput{
+			MaxResults: aws.Int64(50),
+			NextToken:  nextToken,
+		}
+
+		o, err := a.ssmClient.DescribeParameters(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		for _, parameter := range o.Parameters {
+			name := *parameter.Name
+
+			// parameters are listed without their tags, so we only fetch them
+			// for parameters which could be deleted and are not recognized by
+			// name.
+			var tags map[string]string
+			if ciClusterFromPath(name) == "" && !isSSMParameterRecent(parameter) {
+				tags, err = a.ssmParameterTags(name)
+				if err != nil {
+					errors.Append(microerror.Mask(err))
+					a.logger.Log("level", "error", "message", fmt.Sprintf("failed listing tags of ssm parameter %#q: %#v", name, err), "stack", fmt.Sprintf("%#v", err))
+					continue
+				}
+			}
+
+			if !ssmParameterShouldBeDeleted(parameter, tags) {
+				continue
+			}
+
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that ssm parameter %#q should be deleted", name))
+
+			names = append(names, parameter.Name)
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	for len(names) > 0 {
+		n := len(names)
+		if n > maxDeleteParameters {
+			n = maxDeleteParameters
+		}
+
+		i := &ssm.DeleteParametersInput{
+			Names: names[:n],
+		}
+		o, err := a.ssmClient.DeleteParameters(i)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting ssm parameters %s: %#v", strings.Join(aws.StringValueSlice(names[:n]), ", "), err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			for _, name := range o.DeletedParameters {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("deleted ssm parameter %#q", *name))
+			}
+			// invalid parameters do not exist anymore.
+			for _, name := range o.InvalidParameters {
+				a.logger.Log("level", "debug", "message", fmt.Sprintf("ssm parameter %#q does not exist anymore", *name))
+			}
+		}
+
+		names = names[n:]
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) ssmParameterTags(name string) (map[string]string, error) {
+	i := &ssm.ListTagsForResourceInput{
+		ResourceId:   aws.String(name),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	}
+	o, err := a.ssmClient.ListTagsForResource(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	tags := map[string]string{}
+	for _, t := range o.TagList {
+		if t.Key == nil || t.Value == nil {
+			continue
+		}
+		tags[*t.Key] = *t.Value
+	}
+
+	return tags, nil
+}
+
+func ssmParameterShouldBeDeleted(parameter *ssm.ParameterMetadata, tags map[string]string) bool {
+	if ciClusterFromPath(aws.StringValue(parameter.Name)) == "" && !isCITagged(tags) {
+		return false
+	}
+
+	if parameter.LastModifiedDate == nil {
+		// bad formed parameter, should be deleted
+		return true
+	}
+
+	// do not delete recently modified parameters.
+	return !isSSMParameterRecent(parameter)
+}
+
+func isSSMParameterRecent(parameter *ssm.ParameterMetadata) bool {
+	if parameter.LastModifiedDate == nil {
+		return false
+	}
+
+	return time.Now().UTC().Sub(*parameter.LastModifiedDate) < gracePeriod
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+func TestSSMParameterShouldBeDeleted(t *testing.T) {
+	tcs := []struct {
+		parameter   *ssm.ParameterMetadata
+		tags        map[string]string
+		expected    bool
+		description string
+	}{
+		{
+			description: "old ci parameter should be deleted",
+			parameter: &ssm.ParameterMetadata{
+				LastModifiedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:             aws.String("/giantswarm/ci-wip-1a2b3/join-token"),
+			},
+			expected: true,
+		},
+		{
+			description: "old parameter tagged for ci cluster should be deleted",
+			parameter: &ssm.ParameterMetadata{
+				LastModifiedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:             aws.String("/giantswarm/join-token"),
+			},
+			tags: map[string]string{
+				tagCluster: "ci-wip-1a2b3",
+			},
+			expected: true,
+		},
+		{
+			description: "recently modified ci parameter should not be deleted",
+			parameter: &ssm.ParameterMetadata{
+				LastModifiedDate: aws.Time(time.Now()),
+				Name:             aws.String("/giantswarm/ci-wip-1a2b3/join-token"),
+			},
+			expected: false,
+		},
+		{
+			description: "old parameter of ci cluster at top level should be deleted",
+			parameter: &ssm.ParameterMetadata{
+				LastModifiedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:             aws.String("/ci-wip-1a2b3/join-token"),
+			},
+			expected: true,
+		},
+		{
+			description: "old parameter with ci segment deeper in the path should not be deleted",
+			parameter: &ssm.ParameterMetadata{
+				LastModifiedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:             aws.String("/production/ci-settings/foo"),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+		{
+			description: "old other parameter should not be deleted",
+			parameter: &ssm.ParameterMetadata{
+				LastModifiedDate: aws.Time(time.Now().Add(-2 * time.Hour)),
+				Name:             aws.String("/giantswarm/join-token"),
+			},
+			tags:     map[string]string{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := ssmParameterShouldBeDeleted(tc.parameter, tc.tags)
+
+			if tc.expected != actual {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.parameter.Name, tc.expected, actual)
+			}
+		})
+	}
+}
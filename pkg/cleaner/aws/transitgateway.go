@@ -0,0 +1,341 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanTransitGatewayAttachments deletes transit gateway VPC attachments of CI
+// VPCs which do not exist anymore or are about to be deleted. Leftover
+// attachments exhaust the attachment quota of the shared transit gateway and
+// block the deletion of the subnets they are placed in. Before an attachment
+// is deleted its static routes, its route table association and its route
+// table propagations are removed. Attachment requests of CI VPCs which were
+// not accepted within the grace period are rejected.
+func (a *Cleaner) cleanTransitGatewayAttachments() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stacks, err := a.stacks()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	vpcs, err := a.describeVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	ciVPCs := map[string]bool{}
+	for _, vpc := range vpcs {
+		if isCITagged(ec2Tags(vpc.Tags)) {
+			ciVPCs[*vpc.VpcId] = true
+		}
+	}
+
+	var attachments []*ec2.TransitGatewayAttachment
+	{
+		var nextToken *string
+		for {
+			i := &ec2.DescribeTransitGatewayAttachmentsInput{
+				Filters: []*ec2.Filter{
+					{
+						Name: aws.String("resource-type"),
+						Values: []*string{
+							aws.String(ec2.TransitGatewayAttachmentResourceTypeVpc),
+						},
+					},
+				},
+				NextToken: nextToken,
+			}
+
+			o, err := a.ec2Client.DescribeTransitGatewayAttachments(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				return errors
+			}
+
+			attachments = append(attachments, o.TransitGatewayAttachments...)
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	var deleting []*string
+	for _, att := range attachments {
+		id := *att.TransitGatewayAttachmentId
+
+		if transitGatewayAttachmentShouldBeRejected(att, ciVPCs) {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("found that transit gateway attachment %#q should be rejected", id))
+
+			i := &ec2.RejectTransitGatewayVpcAttachmentInput{
+				TransitGatewayAttachmentId: att.TransitGatewayAttachmentId,
+			}
+			_, err := a.ec2Client.RejectTransitGatewayVpcAttachment(i)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				// do not return on error, try to continue rejecting.
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed rejecting transit gateway attachment %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			} else {
+				a.logger.Log("level", "info", "message", fmt.Sprintf("rejected transit gateway attachment %#q", id))
+			}
+
+			continue
+		}
+
+		if !transitGatewayAttachmentShouldBeDeleted(att, vpcs, stacks) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that transit gateway attachment %#q should be deleted", id))
+
+		err := a.deleteTransitGatewayAttachment(att)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting transit gateway attachment %#q: %#v", id, err), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		deleting = append(deleting, att.TransitGatewayAttachmentId)
+	}
+
+	if len(deleting) > 0 {
+		// the network interfaces of the attachments block the deletion of the
+		// VPC subnets until the attachments are gone.
+		a.logger.Log("level", "debug", "message", fmt.Sprintf("waiting for %d transit gateway attachments to be deleted", len(deleting)))
+
+		err := a.waitForTransitGatewayAttachmentsDeleted(deleting)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed waiting for transit gateway attachments to be deleted: %#v", err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted %d transit gateway attachments", len(deleting)))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+func (a *Cleaner) deleteTransitGatewayAttachment(att *ec2.TransitGatewayAttachment) error {
+	// route tables of the transit gateway are only visible to its owner. In
+	// case the transit gateway is shared with us, we find no route tables and
+	// leave the routes to the owner.
+	var routeTables []*ec2.TransitGatewayRouteTable
+	{
+		i := &ec2.DescribeTransitGatewayRouteTablesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name: aws.String("transit-gateway-id"),
+					Values: []*string{
+						att.TransitGatewayId,
+					},
+				},
+			},
+		}
+		o, err := a.ec2Client.DescribeTransitGatewayRouteTables(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		routeTables = o.TransitGatewayRouteTables
+	}
+
+	for _, routeTable := range routeTables {
+		i := &ec2.SearchTransitGatewayRoutesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name: aws.String("attachment.transit-gateway-attachment-id"),
+					Values: []*string{
+						att.TransitGatewayAttachmentId,
+					},
+				},
+				{
+					Name: aws.String("type"),
+					Values: []*string{
+						aws.String(ec2.TransitGatewayRouteTypeStatic),
+					},
+				},
+			},
+			TransitGatewayRouteTableId: routeTable.TransitGatewayRouteTableId,
+		}
+		o, err := a.ec2Client.SearchTransitGatewayRoutes(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, route := range o.Routes {
+			i := &ec2.DeleteTransitGatewayRouteInput{
+				DestinationCidrBlock:       route.DestinationCidrBlock,
+				TransitGatewayRouteTableId: routeTable.TransitGatewayRouteTableId,
+			}
+			_, err := a.ec2Client.DeleteTransitGatewayRoute(i)
+			if IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	if len(routeTables) > 0 {
+		var nextToken *string
+		for {
+			i := &ec2.GetTransitGatewayAttachmentPropagationsInput{
+				NextToken:                  nextToken,
+				TransitGatewayAttachmentId: att.TransitGatewayAttachmentId,
+			}
+			o, err := a.ec2Client.GetTransitGatewayAttachmentPropagations(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for _, propagation := range o.TransitGatewayAttachmentPropagations {
+				i := &ec2.DisableTransitGatewayRouteTablePropagationInput{
+					TransitGatewayAttachmentId: att.TransitGatewayAttachmentId,
+					TransitGatewayRouteTableId: propagation.TransitGatewayRouteTableId,
+				}
+				_, err := a.ec2Client.DisableTransitGatewayRouteTablePropagation(i)
+				if IsNotFound(err) {
+					// fall through
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+
+			if o.NextToken == nil {
+				break
+			}
+			nextToken = o.NextToken
+		}
+	}
+
+	if att.Association != nil && att.Association.TransitGatewayRouteTableId != nil {
+		i := &ec2.DisassociateTransitGatewayRouteTableInput{
+			TransitGatewayAttachmentId: att.TransitGatewayAttachmentId,
+			TransitGatewayRouteTableId: att.Association.TransitGatewayRouteTableId,
+		}
+		_, err := a.ec2Client.DisassociateTransitGatewayRouteTable(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DeleteTransitGatewayVpcAttachmentInput{
+			TransitGatewayAttachmentId: att.TransitGatewayAttachmentId,
+		}
+		_, err := a.ec2Client.DeleteTransitGatewayVpcAttachment(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) waitForTransitGatewayAttachmentsDeleted(ids []*string) error {
+	deleted := func() (bool, error) {
+		i := &ec2.DescribeTransitGatewayAttachmentsInput{
+			TransitGatewayAttachmentIds: ids,
+		}
+		o, err := a.ec2Client.DescribeTransitGatewayAttachments(i)
+		if IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		for _, att := range o.TransitGatewayAttachments {
+			if aws.StringValue(att.State) != ec2.TransitGatewayAttachmentStateDeleted {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+
+	err := waitFor(deleted)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func transitGatewayAttachmentShouldBeDeleted(att *ec2.TransitGatewayAttachment, vpcs []*ec2.Vpc, stacks map[string]*cloudformation.Stack) bool {
+	if aws.StringValue(att.State) != ec2.TransitGatewayAttachmentStateAvailable {
+		return false
+	}
+
+	// do not delete recent attachments.
+	if att.CreationTime != nil && time.Now().UTC().Sub(*att.CreationTime) < gracePeriod {
+		return false
+	}
+
+	var vpc *ec2.Vpc
+	var isOwnAccount bool
+	for _, v := range vpcs {
+		if aws.StringValue(v.VpcId) == aws.StringValue(att.ResourceId) {
+			vpc = v
+		}
+		if aws.StringValue(v.OwnerId) == aws.StringValue(att.ResourceOwnerId) {
+			isOwnAccount = true
+		}
+	}
+
+	// VPCs of other accounts cannot be checked and are therefore considered
+	// to exist.
+	if vpc == nil && !isOwnAccount {
+		return false
+	}
+
+	// the VPC does not exist anymore.
+	if vpc == nil {
+		return isCITagged(ec2Tags(att.Tags))
+	}
+
+	// the VPC is about to be deleted.
+	return vpcShouldBeDeleted(vpc, stacks)
+}
+
+func transitGatewayAttachmentShouldBeRejected(att *ec2.TransitGatewayAttachment, ciVPCs map[string]bool) bool {
+	if aws.StringValue(att.State) != ec2.TransitGatewayAttachmentStatePendingAcceptance {
+		return false
+	}
+
+	// do not reject attachment requests of other VPCs, e.g. of production
+	// clusters.
+	if !ciVPCs[aws.StringValue(att.ResourceId)] && !isCITagged(ec2Tags(att.Tags)) {
+		return false
+	}
+
+	if att.CreationTime == nil {
+		// bad formed attachment, should be rejected
+		return true
+	}
+
+	// do not reject recent attachment requests.
+	if time.Now().UTC().Sub(*att.CreationTime) < gracePeriod {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestTransitGatewayAttachmentShouldBeDeleted(t *testing.T) {
+	vpcs := []*ec2.Vpc{
+		{
+			OwnerId: aws.String("111111111111"),
+			VpcId:   aws.String("vpc-host"),
+		},
+		{
+			OwnerId: aws.String("111111111111"),
+			Tags: []*ec2.Tag{
+				{
+					Key:   aws.String("aws:cloudformation:stack-name"),
+					Value: aws.String("cluster-ci-deleted-guest-main"),
+				},
+			},
+			VpcId: aws.String("vpc-ci"),
+		},
+	}
+	stacks := map[string]*cloudformation.Stack{}
+
+	ciTags := []*ec2.Tag{
+		{
+			Key:   aws.String("giantswarm.io/cluster"),
+			Value: aws.String("ci-wip-50a83-d4f51"),
+		},
+	}
+
+	tcs := []struct {
+		att         *ec2.TransitGatewayAttachment
+		expected    bool
+		description string
+	}{
+		{
+			description: "attachment of existing vpc should not be deleted",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:                 aws.String("vpc-host"),
+				ResourceOwnerId:            aws.String("111111111111"),
+				State:                      aws.String("available"),
+				TransitGatewayAttachmentId: aws.String("tgw-attach-1"),
+			},
+			expected: false,
+		},
+		{
+			description: "attachment of ci vpc about to be deleted should be deleted",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:                 aws.String("vpc-ci"),
+				ResourceOwnerId:            aws.String("111111111111"),
+				State:                      aws.String("available"),
+				TransitGatewayAttachmentId: aws.String("tgw-attach-2"),
+			},
+			expected: true,
+		},
+		{
+			description: "ci attachment of deleted vpc should be deleted",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:                 aws.String("vpc-deleted"),
+				ResourceOwnerId:            aws.String("111111111111"),
+				State:                      aws.String("available"),
+				Tags:                       ciTags,
+				TransitGatewayAttachmentId: aws.String("tgw-attach-3"),
+			},
+			expected: true,
+		},
+		{
+			description: "recent ci attachment of deleted vpc should not be deleted",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now()),
+				ResourceId:                 aws.String("vpc-deleted"),
+				ResourceOwnerId:            aws.String("111111111111"),
+				State:                      aws.String("available"),
+				Tags:                       ciTags,
+				TransitGatewayAttachmentId: aws.String("tgw-attach-4"),
+			},
+			expected: false,
+		},
+		{
+			description: "ci attachment of vpc in other account should not be deleted",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:                 aws.String("vpc-other-account"),
+				ResourceOwnerId:            aws.String("222222222222"),
+				State:                      aws.String("available"),
+				Tags:                       ciTags,
+				TransitGatewayAttachmentId: aws.String("tgw-attach-5"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := transitGatewayAttachmentShouldBeDeleted(tc.att, vpcs, stacks)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.att.TransitGatewayAttachmentId, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTransitGatewayAttachmentShouldBeRejected(t *testing.T) {
+	ciVPCs := map[string]bool{
+		"vpc-ci": true,
+	}
+
+	tcs := []struct {
+		att         *ec2.TransitGatewayAttachment
+		expected    bool
+		description string
+	}{
+		{
+			description: "recent pending attachment should not be rejected",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now()),
+				State:                      aws.String("pendingAcceptance"),
+				TransitGatewayAttachmentId: aws.String("tgw-attach-1"),
+			},
+			expected: false,
+		},
+		{
+			description: "old pending attachment of ci vpc should be rejected",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:                 aws.String("vpc-ci"),
+				State:                      aws.String("pendingAcceptance"),
+				TransitGatewayAttachmentId: aws.String("tgw-attach-2"),
+			},
+			expected: true,
+		},
+		{
+			description: "old pending attachment tagged for ci cluster should be rejected",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime: aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:   aws.String("vpc-other-account"),
+				State:        aws.String("pendingAcceptance"),
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String(tagCluster),
+						Value: aws.String("ci-wip-1a2b3"),
+					},
+				},
+				TransitGatewayAttachmentId: aws.String("tgw-attach-4"),
+			},
+			expected: true,
+		},
+		{
+			description: "old pending attachment of non ci vpc should not be rejected",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				ResourceId:                 aws.String("vpc-production"),
+				State:                      aws.String("pendingAcceptance"),
+				TransitGatewayAttachmentId: aws.String("tgw-attach-5"),
+			},
+			expected: false,
+		},
+		{
+			description: "old available attachment should not be rejected",
+			att: &ec2.TransitGatewayAttachment{
+				CreationTime:               aws.Time(time.Now().Add(-2 * time.Hour)),
+				State:                      aws.String("available"),
+				TransitGatewayAttachmentId: aws.String("tgw-attach-3"),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := transitGatewayAttachmentShouldBeRejected(tc.att, ciVPCs)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be rejected, want %t, got %t", *tc.att.TransitGatewayAttachmentId, tc.expected, actual)
+			}
+		})
+	}
+}
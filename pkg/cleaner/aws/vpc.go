@@ -0,0 +1,798 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/ci-cleaner/pkg/errorcollection"
+	"github.com/giantswarm/microerror"
+)
+
+// cleanVPCs deletes CI VPCs which are left behind, e.g. because the deletion of
+// their stack failed or because they were created by Terraform or cluster-api.
+// All dependents of a VPC are deleted in the order required by AWS before the
+// VPC itself is deleted.
+func (a *Cleaner) cleanVPCs() error {
+	errors := &errorcollection.ErrorCollection{}
+
+	stacks, err := a.stacks()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	vpcs, err := a.describeCIVPCs()
+	if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	for _, vpc := range vpcs {
+		tags := ec2Tags(vpc.Tags)
+
+		// VPCs do not have a creation time. For VPCs which were not created by
+		// CloudFormation we remember the time we saw them first.
+		_, hasStack := tags[tagCloudFormationStack]
+		_, hasFirstSeen := tags[tagFirstSeen]
+		if !hasStack && !hasFirstSeen {
+			err := a.tagFirstSeen(*vpc.VpcId)
+			if err != nil {
+				errors.Append(microerror.Mask(err))
+				a.logger.Log("level", "error", "message", fmt.Sprintf("failed tagging vpc %#q: %#v", *vpc.VpcId, err), "stack", fmt.Sprintf("%#v", err))
+			}
+			continue
+		}
+
+		if !vpcShouldBeDeleted(vpc, stacks) {
+			continue
+		}
+
+		a.logger.Log("level", "info", "message", fmt.Sprintf("found that vpc %#q should be deleted", *vpc.VpcId))
+
+		err := a.deleteVPC(*vpc.VpcId)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			// do not return on error, try to continue deleting.
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed deleting vpc %#q: %#v", *vpc.VpcId, err), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			a.logger.Log("level", "info", "message", fmt.Sprintf("deleted vpc %#q", *vpc.VpcId))
+		}
+	}
+
+	if errors.HasErrors() {
+		return errors
+	}
+	return nil
+}
+
+// describeVPCs returns all VPCs of the region.
+func (a *Cleaner) describeVPCs() ([]*ec2.Vpc, error) {
+	var vpcs []*ec2.Vpc
+
+	var nextToken *string
+	for {
+		i := &ec2.DescribeVpcsInput{
+			NextToken: nextToken,
+		}
+
+		o, err := a.ec2Client.DescribeVpcs(i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		vpcs = append(vpcs, o.Vpcs...)
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return vpcs, nil
+}
+
+// describeCIVPCs returns all VPCs which are tagged as belonging to a CI cluster
+// or CI stack.
+func (a *Cleaner) describeCIVPCs() ([]*ec2.Vpc, error) {
+	vpcs, err := a.describeVPCs()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var ciVPCs []*ec2.Vpc
+	for _, vpc := range vpcs {
+		if isCITagged(ec2Tags(vpc.Tags)) {
+			ciVPCs = append(ciVPCs, vpc)
+		}
+	}
+
+	return ciVPCs, nil
+}
+
+// ciVPCs returns the IDs of all VPCs which are tagged as belonging to a CI
+// cluster or CI stack.
+func (a *Cleaner) ciVPCs() (map[string]bool, error) {
+	vpcs, err := a.describeCIVPCs()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	ids := map[string]bool{}
+	for _, vpc := range vpcs {
+		ids[*vpc.VpcId] = true
+	}
+
+	return ids, nil
+}
+
+// deleteVPC deletes all dependents of the given VPC and the VPC itself. The
+// dependents are deleted in the order required by AWS. In case the VPC cannot
+// be deleted, the returned error names the dependents which are left.
+func (a *Cleaner) deleteVPC(vpcID string) error {
+	errors := &errorcollection.ErrorCollection{}
+
+	steps := []func(string) error{
+		a.deleteVPCInstances,
+		a.deleteVPCNatGateways,
+		a.deleteVPCNetworkInterfaces,
+		a.deleteVPCEndpoints,
+		a.deleteVPCInternetGateways,
+		a.deleteVPCEgressOnlyInternetGateways,
+		a.deleteVPCSubnets,
+		a.deleteVPCRouteTables,
+		a.deleteVPCNetworkAcls,
+		a.deleteVPCSecurityGroups,
+	}
+
+	// We try all steps even if one of them fails, so that the VPC gets as
+	// close to being deletable as possible.
+	for _, f := range steps {
+		err := f(vpcID)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			a.logger.Log("level", "error", "message", fmt.Sprintf("failed running %s for vpc %#q", getFunctionName(f), vpcID), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	i := &ec2.DeleteVpcInput{
+		VpcId: aws.String(vpcID),
+	}
+	_, err := a.ec2Client.DeleteVpc(i)
+	if IsNotFound(err) {
+		return nil
+	} else if IsDependencyViolation(err) {
+		dependencies, err := a.vpcDependencies(vpcID)
+		if err != nil {
+			errors.Append(microerror.Mask(err))
+			return errors
+		}
+
+		errors.Append(microerror.Maskf(dependencyViolationError, "vpc %#q is still used by %s", vpcID, strings.Join(dependencies, ", ")))
+		return errors
+	} else if err != nil {
+		errors.Append(microerror.Mask(err))
+		return errors
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCInstances(vpcID string) error {
+	i := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+			{
+				Name: aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{
+					ec2.InstanceStateNamePending,
+					ec2.InstanceStateNameRunning,
+					ec2.InstanceStateNameShuttingDown,
+					ec2.InstanceStateNameStopping,
+					ec2.InstanceStateNameStopped,
+				}),
+			},
+		},
+	}
+	o, err := a.ec2Client.DescribeInstances(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var ids []*string
+	for _, reservation := range o.Reservations {
+		for _, instance := range reservation.Instances {
+			ids = append(ids, instance.InstanceId)
+
+			if aws.StringValue(instance.State.Name) == ec2.InstanceStateNameShuttingDown {
+				continue
+			}
+
+			i := &ec2.ModifyInstanceAttributeInput{
+				DisableApiTermination: &ec2.AttributeBooleanValue{
+					Value: aws.Bool(false),
+				},
+				InstanceId: instance.InstanceId,
+			}
+			_, err = a.ec2Client.ModifyInstanceAttribute(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	{
+		i := &ec2.TerminateInstancesInput{
+			InstanceIds: ids,
+		}
+		_, err := a.ec2Client.TerminateInstances(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	{
+		i := &ec2.DescribeInstancesInput{
+			InstanceIds: ids,
+		}
+		err := a.ec2Client.WaitUntilInstanceTerminated(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCNatGateways(vpcID string) error {
+	i := &ec2.DescribeNatGatewaysInput{
+		Filter: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeNatGateways(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var gateways []*ec2.NatGateway
+	for _, nat := range o.NatGateways {
+		if aws.StringValue(nat.State) != ec2.NatGatewayStateDeleted {
+			gateways = append(gateways, nat)
+		}
+	}
+
+	err = a.deleteNatGateways(gateways)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCNetworkInterfaces(vpcID string) error {
+	enis, err := a.vpcNetworkInterfaces(vpcID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, eni := range enis {
+		// interfaces managed by AWS services and attached interfaces are
+		// deleted together with their owner.
+		if aws.BoolValue(eni.RequesterManaged) || aws.StringValue(eni.Status) != ec2.NetworkInterfaceStatusAvailable {
+			continue
+		}
+
+		i := &ec2.DeleteNetworkInterfaceInput{
+			NetworkInterfaceId: eni.NetworkInterfaceId,
+		}
+		_, err := a.ec2Client.DeleteNetworkInterface(i)
+		if IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCEndpoints(vpcID string) error {
+	endpoints, err := a.vpcEndpoints(vpcID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	var ids []*string
+	for _, endpoint := range endpoints {
+		ids = append(ids, endpoint.VpcEndpointId)
+	}
+
+	i := &ec2.DeleteVpcEndpointsInput{
+		VpcEndpointIds: ids,
+	}
+	o, err := a.ec2Client.DeleteVpcEndpoints(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for _, item := range o.Unsuccessful {
+		if item.Error != nil && !strings.HasSuffix(aws.StringValue(item.Error.Code), ".NotFound") {
+			return microerror.Maskf(executionFailedError, "%s", unsuccessfulItemMessage(item))
+		}
+	}
+
+	// interface endpoints release their network interfaces asynchronously,
+	// which block the deletion of the subnets until they are gone.
+	deleted := func() (bool, error) {
+		endpoints, err := a.vpcEndpoints(vpcID)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return len(endpoints) == 0, nil
+	}
+
+	err = waitFor(deleted)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCInternetGateways(vpcID string) error {
+	gateways, err := a.vpcInternetGateways(vpcID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, gateway := range gateways {
+		{
+			i := &ec2.DetachInternetGatewayInput{
+				InternetGatewayId: gateway.InternetGatewayId,
+				VpcId:             aws.String(vpcID),
+			}
+			_, err := a.ec2Client.DetachInternetGateway(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		{
+			i := &ec2.DeleteInternetGatewayInput{
+				InternetGatewayId: gateway.InternetGatewayId,
+			}
+			_, err := a.ec2Client.DeleteInternetGateway(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCEgressOnlyInternetGateways(vpcID string) error {
+	var nextToken *string
+	for {
+		i := &ec2.DescribeEgressOnlyInternetGatewaysInput{
+			NextToken: nextToken,
+		}
+		o, err := a.ec2Client.DescribeEgressOnlyInternetGateways(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, gateway := range o.EgressOnlyInternetGateways {
+			if !isAttachedToVPC(gateway.Attachments, vpcID) {
+				continue
+			}
+
+			i := &ec2.DeleteEgressOnlyInternetGatewayInput{
+				EgressOnlyInternetGatewayId: gateway.EgressOnlyInternetGatewayId,
+			}
+			_, err := a.ec2Client.DeleteEgressOnlyInternetGateway(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		if o.NextToken == nil {
+			break
+		}
+		nextToken = o.NextToken
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCSubnets(vpcID string) error {
+	subnets, err := a.vpcSubnets(vpcID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, subnet := range subnets {
+		i := &ec2.DeleteSubnetInput{
+			SubnetId: subnet.SubnetId,
+		}
+		_, err := a.ec2Client.DeleteSubnet(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCRouteTables(vpcID string) error {
+	routeTables, err := a.vpcRouteTables(vpcID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, routeTable := range routeTables {
+		for _, association := range routeTable.Associations {
+			i := &ec2.DisassociateRouteTableInput{
+				AssociationId: association.RouteTableAssociationId,
+			}
+			_, err := a.ec2Client.DisassociateRouteTable(i)
+			if IsNotFound(err) {
+				// fall through
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		i := &ec2.DeleteRouteTableInput{
+			RouteTableId: routeTable.RouteTableId,
+		}
+		_, err := a.ec2Client.DeleteRouteTable(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCNetworkAcls(vpcID string) error {
+	i := &ec2.DescribeNetworkAclsInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeNetworkAcls(i)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, acl := range o.NetworkAcls {
+		// the default network ACL is deleted together with the VPC.
+		if aws.BoolValue(acl.IsDefault) {
+			continue
+		}
+
+		i := &ec2.DeleteNetworkAclInput{
+			NetworkAclId: acl.NetworkAclId,
+		}
+		_, err := a.ec2Client.DeleteNetworkAcl(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Cleaner) deleteVPCSecurityGroups(vpcID string) error {
+	groups, err := a.vpcSecurityGroups(vpcID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// security groups referencing each other cannot be deleted, so we revoke
+	// all rules referencing other groups first.
+	for _, group := range groups {
+		ingress := permissionsReferencingGroups(group.IpPermissions)
+		if len(ingress) > 0 {
+			i := &ec2.RevokeSecurityGroupIngressInput{
+				GroupId:       group.GroupId,
+				IpPermissions: ingress,
+			}
+			_, err := a.ec2Client.RevokeSecurityGroupIngress(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		egress := permissionsReferencingGroups(group.IpPermissionsEgress)
+		if len(egress) > 0 {
+			i := &ec2.RevokeSecurityGroupEgressInput{
+				GroupId:       group.GroupId,
+				IpPermissions: egress,
+			}
+			_, err := a.ec2Client.RevokeSecurityGroupEgress(i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		i := &ec2.DeleteSecurityGroupInput{
+			GroupId: group.GroupId,
+		}
+		_, err := a.ec2Client.DeleteSecurityGroup(i)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// vpcDependencies returns a description of the dependents which block the
+// deletion of the given VPC.
+func (a *Cleaner) vpcDependencies(vpcID string) ([]string, error) {
+	var dependencies []string
+
+	{
+		enis, err := a.vpcNetworkInterfaces(vpcID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, eni := range enis {
+			dependencies = append(dependencies, fmt.Sprintf("network interface %#q (%s)", *eni.NetworkInterfaceId, aws.StringValue(eni.Description)))
+		}
+	}
+
+	{
+		endpoints, err := a.vpcEndpoints(vpcID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, endpoint := range endpoints {
+			dependencies = append(dependencies, fmt.Sprintf("vpc endpoint %#q", *endpoint.VpcEndpointId))
+		}
+	}
+
+	{
+		gateways, err := a.vpcInternetGateways(vpcID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, gateway := range gateways {
+			dependencies = append(dependencies, fmt.Sprintf("internet gateway %#q", *gateway.InternetGatewayId))
+		}
+	}
+
+	{
+		subnets, err := a.vpcSubnets(vpcID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, subnet := range subnets {
+			dependencies = append(dependencies, fmt.Sprintf("subnet %#q", *subnet.SubnetId))
+		}
+	}
+
+	{
+		routeTables, err := a.vpcRouteTables(vpcID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, routeTable := range routeTables {
+			dependencies = append(dependencies, fmt.Sprintf("route table %#q", *routeTable.RouteTableId))
+		}
+	}
+
+	{
+		groups, err := a.vpcSecurityGroups(vpcID)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, group := range groups {
+			dependencies = append(dependencies, fmt.Sprintf("security group %#q", *group.GroupId))
+		}
+	}
+
+	if len(dependencies) == 0 {
+		dependencies = append(dependencies, "unknown dependents")
+	}
+
+	return dependencies, nil
+}
+
+func (a *Cleaner) vpcNetworkInterfaces(vpcID string) ([]*ec2.NetworkInterface, error) {
+	i := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeNetworkInterfaces(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return o.NetworkInterfaces, nil
+}
+
+// vpcEndpoints returns the endpoints of the given VPC which are not deleted
+// yet.
+func (a *Cleaner) vpcEndpoints(vpcID string) ([]*ec2.VpcEndpoint, error) {
+	i := &ec2.DescribeVpcEndpointsInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeVpcEndpoints(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var endpoints []*ec2.VpcEndpoint
+	for _, endpoint := range o.VpcEndpoints {
+		if strings.EqualFold(aws.StringValue(endpoint.State), ec2.StateDeleted) {
+			continue
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, nil
+}
+
+func (a *Cleaner) vpcInternetGateways(vpcID string) ([]*ec2.InternetGateway, error) {
+	i := &ec2.DescribeInternetGatewaysInput{
+		Filters: []*ec2.Filter{
+			{
+				Name: aws.String("attachment.vpc-id"),
+				Values: []*string{
+					aws.String(vpcID),
+				},
+			},
+		},
+	}
+	o, err := a.ec2Client.DescribeInternetGateways(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return o.InternetGateways, nil
+}
+
+func (a *Cleaner) vpcSubnets(vpcID string) ([]*ec2.Subnet, error) {
+	i := &ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeSubnets(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return o.Subnets, nil
+}
+
+// vpcRouteTables returns the route tables of the given VPC except the main
+// route table, which is deleted together with the VPC.
+func (a *Cleaner) vpcRouteTables(vpcID string) ([]*ec2.RouteTable, error) {
+	i := &ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeRouteTables(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var routeTables []*ec2.RouteTable
+	for _, routeTable := range o.RouteTables {
+		if isMainRouteTable(routeTable) {
+			continue
+		}
+		routeTables = append(routeTables, routeTable)
+	}
+
+	return routeTables, nil
+}
+
+// vpcSecurityGroups returns the security groups of the given VPC except the
+// default security group, which is deleted together with the VPC.
+func (a *Cleaner) vpcSecurityGroups(vpcID string) ([]*ec2.SecurityGroup, error) {
+	i := &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			vpcFilter(vpcID),
+		},
+	}
+	o, err := a.ec2Client.DescribeSecurityGroups(i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var groups []*ec2.SecurityGroup
+	for _, group := range o.SecurityGroups {
+		if aws.StringValue(group.GroupName) == "default" {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func vpcShouldBeDeleted(vpc *ec2.Vpc, stacks map[string]*cloudformation.Stack) bool {
+	tags := ec2Tags(vpc.Tags)
+
+	if !isCITagged(tags) {
+		return false
+	}
+
+	// VPCs of existing stacks are deleted together with their stack. We only
+	// take over in case the deletion of the stack failed.
+	if name, ok := tags[tagCloudFormationStack]; ok {
+		stack, ok := stacks[name]
+		if !ok {
+			return true
+		}
+
+		return aws.StringValue(stack.StackStatus) == cloudformation.StackStatusDeleteFailed
+	}
+
+	// do not delete VPCs we saw first only recently.
+	return isFirstSeenBeforeGracePeriod(tags)
+}
+
+func vpcFilter(vpcID string) *ec2.Filter {
+	return &ec2.Filter{
+		Name: aws.String("vpc-id"),
+		Values: []*string{
+			aws.String(vpcID),
+		},
+	}
+}
+
+func isAttachedToVPC(attachments []*ec2.InternetGatewayAttachment, vpcID string) bool {
+	for _, attachment := range attachments {
+		if aws.StringValue(attachment.VpcId) == vpcID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isMainRouteTable(routeTable *ec2.RouteTable) bool {
+	for _, association := range routeTable.Associations {
+		if aws.BoolValue(association.Main) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// permissionsReferencingGroups returns the given permissions which reference
+// other security groups.
+func permissionsReferencingGroups(permissions []*ec2.IpPermission) []*ec2.IpPermission {
+	var referencing []*ec2.IpPermission
+	for _, p := range permissions {
+		if len(p.UserIdGroupPairs) > 0 {
+			referencing = append(referencing, p)
+		}
+	}
+
+	return referencing
+}
@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestVPCShouldBeDeleted(t *testing.T) {
+	stacks := map[string]*cloudformation.Stack{
+		"cluster-ci-existing-guest-main": {
+			StackName:   aws.String("cluster-ci-existing-guest-main"),
+			StackStatus: aws.String("CREATE_COMPLETE"),
+		},
+		"cluster-ci-failed-guest-main": {
+			StackName:   aws.String("cluster-ci-failed-guest-main"),
+			StackStatus: aws.String("DELETE_FAILED"),
+		},
+	}
+
+	tcs := []struct {
+		vpc         *ec2.Vpc
+		expected    bool
+		description string
+	}{
+		{
+			description: "untagged vpc should not be deleted",
+			vpc: &ec2.Vpc{
+				VpcId: aws.String("vpc-1"),
+			},
+			expected: false,
+		},
+		{
+			description: "vpc of existing ci stack should not be deleted",
+			vpc: &ec2.Vpc{
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("aws:cloudformation:stack-name"),
+						Value: aws.String("cluster-ci-existing-guest-main"),
+					},
+				},
+				VpcId: aws.String("vpc-2"),
+			},
+			expected: false,
+		},
+		{
+			description: "vpc of failed ci stack should be deleted",
+			vpc: &ec2.Vpc{
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("aws:cloudformation:stack-name"),
+						Value: aws.String("cluster-ci-failed-guest-main"),
+					},
+				},
+				VpcId: aws.String("vpc-3"),
+			},
+			expected: true,
+		},
+		{
+			description: "vpc of deleted ci stack should be deleted",
+			vpc: &ec2.Vpc{
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("aws:cloudformation:stack-name"),
+						Value: aws.String("cluster-ci-deleted-guest-main"),
+					},
+				},
+				VpcId: aws.String("vpc-4"),
+			},
+			expected: true,
+		},
+		{
+			description: "ci vpc without stack not seen before should not be deleted",
+			vpc: &ec2.Vpc{
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("sigs.k8s.io/cluster-api-provider-aws/cluster/ci-capa-1a2b3"),
+						Value: aws.String("owned"),
+					},
+				},
+				VpcId: aws.String("vpc-5"),
+			},
+			expected: false,
+		},
+		{
+			description: "ci vpc without stack seen recently should not be deleted",
+			vpc: &ec2.Vpc{
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("sigs.k8s.io/cluster-api-provider-aws/cluster/ci-capa-1a2b3"),
+						Value: aws.String("owned"),
+					},
+					{
+						Key:   aws.String("ci-cleaner.giantswarm.io/first-seen"),
+						Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-6"),
+			},
+			expected: false,
+		},
+		{
+			description: "ci vpc without stack seen long ago should be deleted",
+			vpc: &ec2.Vpc{
+				Tags: []*ec2.Tag{
+					{
+						Key:   aws.String("sigs.k8s.io/cluster-api-provider-aws/cluster/ci-capa-1a2b3"),
+						Value: aws.String("owned"),
+					},
+					{
+						Key:   aws.String("ci-cleaner.giantswarm.io/first-seen"),
+						Value: aws.String(time.Now().UTC().Add(-2 * time.Hour).Format(time.RFC3339)),
+					},
+				},
+				VpcId: aws.String("vpc-7"),
+			},
+			expected: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.description, func(t *testing.T) {
+			actual := vpcShouldBeDeleted(tc.vpc, stacks)
+
+			if actual != tc.expected {
+				t.Errorf("checking if %q should be deleted, want %t, got %t", *tc.vpc.VpcId, tc.expected, actual)
+			}
+		})
+	}
+}